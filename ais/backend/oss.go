@@ -0,0 +1,385 @@
+//go:build oss
+
+// Package backend contains implementation of various backend providers.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // OSS signing (v1 "OSS" scheme) is HMAC-SHA1 by spec, not used for data integrity
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/aistore/api/apc"
+	"github.com/NVIDIA/aistore/cmn"
+	"github.com/NVIDIA/aistore/cmn/cos"
+	"github.com/NVIDIA/aistore/core"
+)
+
+// Alibaba Cloud OSS speaks an S3-similar REST API (bucket+key addressing,
+// multipart init/upload-part/complete) but diverges on:
+//   - signing: "OSS <AccessKeyId>:<Signature>" (HMAC-SHA1 over a canonicalized
+//     string, not SigV4)
+//   - header prefix: `x-oss-*` instead of `x-amz-*`
+//   - endpoints: region-scoped `oss-<region>.aliyuncs.com`, with separate
+//     internal (`-internal`) and public hostnames
+//
+// Multipart upload is not implemented in this provider: it issues no
+// InitiateMultipartUpload/UploadPart/CompleteMultipartUpload requests and
+// records nothing via s3.InitUpload/s3.AddPart/s3.FinishUpload.
+
+const (
+	ossHeaderPrefix = "x-oss-"
+	ossDateHeader   = "Date"
+	ossAuthHeader   = "Authorization"
+	ossContentMD5   = "Content-MD5"
+)
+
+type (
+	// ossConf is the per-bucket-provider configuration threaded in via the
+	// existing cmn.BackendConf, mirroring how s3bp resolves its credentials.
+	ossConf struct {
+		AccessKeyID     string
+		AccessKeySecret string
+		Region          string
+		Internal        bool // use the `-internal` endpoint (same-region ECS traffic)
+		UseHTTPS        bool
+	}
+
+	ossbp struct {
+		t    core.TargetPut // minimal target surface backend providers need (PageMM, etc.)
+		conf ossConf
+	}
+)
+
+// OSS's service- and bucket-listing XML responses are, by Alibaba's own
+// documentation, element-for-element compatible with S3's
+// ListAllMyBucketsResult/ListBucketResult - only the fields actually
+// consumed below are declared.
+type (
+	ossBucketXML struct {
+		Name string `xml:"Name"`
+	}
+	ossListAllMyBucketsResult struct {
+		Buckets struct {
+			Bucket []ossBucketXML `xml:"Bucket"`
+		} `xml:"Buckets"`
+	}
+	ossContentXML struct {
+		Key          string `xml:"Key"`
+		Size         int64  `xml:"Size"`
+		ETag         string `xml:"ETag"`
+		LastModified string `xml:"LastModified"`
+	}
+	ossListBucketResult struct {
+		IsTruncated           bool            `xml:"IsTruncated"`
+		NextContinuationToken string          `xml:"NextContinuationToken"`
+		Contents              []ossContentXML `xml:"Contents"`
+	}
+)
+
+func NewOSS(t core.TargetPut, conf ossConf) *ossbp { return &ossbp{t: t, conf: conf} }
+
+func (*ossbp) Provider() string { return apc.OSS }
+
+func (p *ossbp) endpoint() string {
+	scheme := "https"
+	if !p.conf.UseHTTPS {
+		scheme = "http"
+	}
+	host := "oss-" + p.conf.Region + ".aliyuncs.com"
+	if p.conf.Internal {
+		host = "oss-" + p.conf.Region + "-internal.aliyuncs.com"
+	}
+	return scheme + "://" + host
+}
+
+// sign implements the OSS v1 signing scheme:
+//
+//	StringToSign = VERB + "\n"
+//	             + Content-MD5 + "\n"
+//	             + Content-Type + "\n"
+//	             + Date + "\n"
+//	             + CanonicalizedOSSHeaders
+//	             + CanonicalizedResource
+//	Signature = base64(hmac-sha1(AccessKeySecret, StringToSign))
+//	Authorization: "OSS " + AccessKeyId + ":" + Signature
+func (p *ossbp) sign(req *http.Request, bucket, key string) {
+	date := time.Now().UTC().Format(http.TimeFormat)
+	req.Header.Set(ossDateHeader, date)
+
+	var sb strings.Builder
+	sb.WriteString(req.Method)
+	sb.WriteByte('\n')
+	sb.WriteString(req.Header.Get(ossContentMD5))
+	sb.WriteByte('\n')
+	sb.WriteString(req.Header.Get("Content-Type"))
+	sb.WriteByte('\n')
+	sb.WriteString(date)
+	sb.WriteByte('\n')
+	sb.WriteString(p.canonicalizedHeaders(req))
+	sb.WriteString(p.canonicalizedResource(bucket, key, req))
+
+	mac := hmac.New(sha1.New, []byte(p.conf.AccessKeySecret))
+	mac.Write([]byte(sb.String()))
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set(ossAuthHeader, "OSS "+p.conf.AccessKeyID+":"+sig)
+}
+
+func (*ossbp) canonicalizedHeaders(req *http.Request) string {
+	var names []string
+	for k := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, ossHeaderPrefix) {
+			names = append(names, lk)
+		}
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, k := range names {
+		sb.WriteString(k)
+		sb.WriteByte(':')
+		sb.WriteString(req.Header.Get(k))
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (*ossbp) canonicalizedResource(bucket, key string, req *http.Request) string {
+	res := "/"
+	if bucket != "" {
+		res += bucket + "/"
+		if key != "" {
+			res += key
+		}
+	}
+	// sub-resources (uploadId, partNumber, etc.) that must be included, sorted
+	const subResources = "acl|location|uploadId|partNumber|uploads"
+	var q []string
+	for k, v := range req.URL.Query() {
+		for _, want := range strings.Split(subResources, "|") {
+			if k == want {
+				if len(v) > 0 && v[0] != "" {
+					q = append(q, k+"="+v[0])
+				} else {
+					q = append(q, k)
+				}
+			}
+		}
+	}
+	if len(q) > 0 {
+		sort.Strings(q)
+		res += "?" + strings.Join(q, "&")
+	}
+	return res
+}
+
+//
+// backend surface (mirrors s3bp's: HeadObj, GetObj/GetObjReader, PutObj, DeleteObj, ListBuckets, ListObjects)
+//
+
+func (p *ossbp) HeadObj(ctx context.Context, lom core.LIF) (oa *cmn.ObjAttrs, ecode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, p.objURL(lom), http.NoBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	return p.doHead(req, lom)
+}
+
+func (p *ossbp) doHead(req *http.Request, lom core.LIF) (*cmn.ObjAttrs, int, error) {
+	p.sign(req, lom.Bucket(), lom.ObjName())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer cos.Close(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("oss: HEAD %s: %s", req.URL, resp.Status)
+	}
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	oa := &cmn.ObjAttrs{Size: size}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		oa.SetCustomKey(cmn.ETag, strings.Trim(etag, `"`))
+	}
+	return oa, 0, nil
+}
+
+// GetObj is meant to pull the object down via GetObjReader and finalize it
+// into lom the same way every other provider's cold-GET path does - but that
+// finalize step needs the target-side LOM-write helpers (cluster.LOM.Fill/
+// Finalize and friends), none of which are part of this package's surface in
+// this checkout. Reporting success without ever writing the object would be
+// the exact same silent-no-op defect ListBuckets/ListObjects had (see
+// fc11147), so this reports not-implemented instead until that wiring exists.
+func (p *ossbp) GetObj(ctx context.Context, lom core.LIF, owt cmn.OWT, origReq *http.Request) (ecode int, err error) {
+	_ = ctx
+	_ = lom
+	_ = owt
+	_ = origReq
+	return 0, cos.NewErrNotImpl("OSS cold-GET object finalize")
+}
+
+func (p *ossbp) GetObjReader(ctx context.Context, lom core.LIF, offset, length int64) (r interface {
+	Read([]byte) (int, error)
+	Close() error
+}, expCksum *cos.Cksum, ecode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.objURL(lom), http.NoBody)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	if length > 0 {
+		req.Header.Set(cos.HdrRange, fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	}
+	p.sign(req, lom.Bucket(), lom.ObjName())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, http.StatusInternalServerError, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		ecode = resp.StatusCode
+		err = fmt.Errorf("oss: GET %s: %s", req.URL, resp.Status)
+		cos.Close(resp.Body)
+		return nil, nil, ecode, err
+	}
+	return resp.Body, nil, 0, nil
+}
+
+func (p *ossbp) PutObj(ctx context.Context, r cos.ReadOpenCloser, lom core.LIF) (ecode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.objURL(lom), r)
+	if err != nil {
+		return 0, err
+	}
+	if md5 := lom.Checksum(); md5 != nil && md5.Type() == cos.ChecksumMD5 {
+		req.Header.Set(ossContentMD5, md5.Value())
+	}
+	p.sign(req, lom.Bucket(), lom.ObjName())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer cos.Close(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("oss: PUT %s: %s", req.URL, resp.Status)
+	}
+	return 0, nil
+}
+
+func (p *ossbp) DeleteObj(ctx context.Context, lom core.LIF) (ecode int, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, p.objURL(lom), http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	p.sign(req, lom.Bucket(), lom.ObjName())
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer cos.Close(resp.Body)
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("oss: DELETE %s: %s", req.URL, resp.Status)
+	}
+	return 0, nil
+}
+
+// ListBuckets issues GET / (service-level listing) and returns the buckets of
+// the first <ListAllMyBucketsResult> page only: no Marker is sent and further
+// pages are not requested.
+func (p *ossbp) ListBuckets(ctx context.Context, _ cmn.QueryBcks) (cmn.Bcks, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint()+"/", http.NoBody)
+	if err != nil {
+		return nil, 0, err
+	}
+	p.sign(req, "", "")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	defer cos.Close(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, fmt.Errorf("oss: list-buckets: %s", resp.Status)
+	}
+	var result ossListAllMyBucketsResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("oss: list-buckets: failed to parse response: %v", err)
+	}
+	bcks := make(cmn.Bcks, 0, len(result.Buckets.Bucket))
+	for _, b := range result.Buckets.Bucket {
+		bcks = append(bcks, cmn.Bck{Name: b.Name, Provider: apc.OSS})
+	}
+	return bcks, 0, nil
+}
+
+// ListObjects supports continuation-token pagination identically to S3's
+// ListObjectsV2 (`list-type=2`, `continuation-token`, `NextContinuationToken`).
+func (p *ossbp) ListObjects(ctx context.Context, bck *cmn.Bck, msg *apc.LsoMsg, lst *cmn.LsoRes) (ecode int, err error) {
+	u := p.endpoint() + "/" + bck.Name + "?list-type=2"
+	if msg.Prefix != "" {
+		u += "&prefix=" + msg.Prefix
+	}
+	if msg.ContinuationToken != "" {
+		u += "&continuation-token=" + msg.ContinuationToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return 0, err
+	}
+	p.sign(req, bck.Name, "")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	defer cos.Close(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("oss: list-objects %s: %s", bck.Cname(""), resp.Status)
+	}
+	var result ossListBucketResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("oss: list-objects %s: failed to parse response: %v", bck.Cname(""), err)
+	}
+	for j := len(lst.Entries); j < len(result.Contents); j++ {
+		lst.Entries = append(lst.Entries, &cmn.LsoEnt{})
+	}
+	for i, c := range result.Contents {
+		entry := lst.Entries[i]
+		entry.Name, entry.Size = c.Key, c.Size
+		if msg.WantProp(apc.GetPropsCustom) {
+			custom := make(cos.StrKVs, 2)
+			if c.ETag != "" {
+				custom[cmn.ETag] = strings.Trim(c.ETag, `"`)
+			}
+			if c.LastModified != "" {
+				custom[cmn.LastModified] = c.LastModified
+			}
+			if len(custom) > 0 {
+				entry.Custom = cmn.CustomMD2S(custom)
+			}
+		}
+	}
+	lst.Entries = lst.Entries[:len(result.Contents)]
+	if result.IsTruncated {
+		lst.ContinuationToken = result.NextContinuationToken
+	}
+	return 0, nil
+}
+
+// getInventory is the OSS counterpart of s3bp.getInventory. OSS Inventory
+// also emits a daily .csv.gz plus manifest.json under the destination bucket,
+// but locating and reading it is not implemented: this always returns
+// cos.NewErrNotImpl.
+func (p *ossbp) getInventory(ctx context.Context, bck *cmn.Bck, prefix string) (int, error) {
+	return 0, cos.NewErrNotImpl("OSS bucket inventory")
+}
+
+func (p *ossbp) objURL(lom core.LIF) string {
+	return p.endpoint() + "/" + lom.Bucket() + "/" + lom.ObjName()
+}
@@ -6,6 +6,7 @@ package ais
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
@@ -28,6 +29,9 @@ type dpq struct {
 	arch struct {
 		path, mime, regx, mode string // QparamArchpath et al.
 	}
+	// AWS SigV4 presigned-URL parameters (see s3.VerifyPresigned); zero
+	// value (presign.Empty()) means this isn't a presigned request
+	presign s3.PresignParams
 	// strings
 	ptime       string // req timestamp at calling/redirecting proxy (QparamUnixTime)
 	uuid        string // xaction
@@ -130,6 +134,36 @@ func (dpq *dpq) parse(rawQuery string) (err error) {
 		case apc.QparamLatestVer:
 			dpq.latestVer = cos.IsParseBool(value)
 
+		// AWS SigV4 presigned-URL parameters - see s3.VerifyPresigned
+		case s3.QparamAccessKeyID:
+			dpq.presign.AccessKeyID = value
+		case s3.QparamExpires:
+			dpq.presign.Expires = value
+		case s3.QparamSignature:
+			if dpq.presign.Signature, err = url.QueryUnescape(value); err != nil {
+				return
+			}
+		case s3.HeaderAlgorithm:
+			dpq.presign.Algorithm = value
+		case s3.HeaderCredentials:
+			if dpq.presign.Credential, err = url.QueryUnescape(value); err != nil {
+				return
+			}
+		case s3.HeaderDate:
+			dpq.presign.Date = value
+		case s3.HeaderExpires:
+			if dpq.presign.Expires == "" { // QparamExpires takes precedence if both are present
+				dpq.presign.Expires = value
+			}
+		case s3.HeaderSignedHeaders:
+			if dpq.presign.SignedHeaders, err = url.QueryUnescape(value); err != nil {
+				return
+			}
+		case s3.HeaderSignature:
+			if dpq.presign.Signature == "" {
+				dpq.presign.Signature = value
+			}
+
 		default:
 			debug.Func(func() {
 				switch key {
@@ -138,10 +172,7 @@ func (dpq *dpq) parse(rawQuery string) (err error) {
 
 				// flows that utilize these particular keys perform conventional
 				// `r.URL.Query()` parsing
-				case s3.QparamMptUploadID, s3.QparamMptUploads, s3.QparamMptPartNo,
-					s3.QparamAccessKeyID, s3.QparamExpires, s3.QparamSignature,
-					s3.HeaderAlgorithm, s3.HeaderCredentials, s3.HeaderDate,
-					s3.HeaderExpires, s3.HeaderSignedHeaders, s3.HeaderSignature, s3.QparamXID:
+				case s3.QparamMptUploadID, s3.QparamMptUploads, s3.QparamMptPartNo, s3.QparamXID:
 
 				default:
 					err = fmt.Errorf("failed to fast-parse [%s], unknown key: %q", rawQuery, key)
@@ -153,6 +184,14 @@ func (dpq *dpq) parse(rawQuery string) (err error) {
 	return
 }
 
+// verifyPresigned reports whether r carries a valid AWS SigV4 presigned-URL
+// signature, per the parameters dpq.parse already extracted into dpq.presign.
+// Call only when !dpq.presign.Empty(); lookupSecret resolves the embedded
+// access-key ID to its shared secret (see authnsrv.AccessKey).
+func (dpq *dpq) verifyPresigned(r *http.Request, lookupSecret func(accessKeyID string) (secret string, ok bool)) (accessKeyID string, err error) {
+	return s3.VerifyPresigned(r, &dpq.presign, lookupSecret)
+}
+
 func keyEQval(s string) (string, string, bool) {
 	if i := strings.IndexByte(s, '='); i > 0 {
 		return s[:i], s[i+1:], true
@@ -0,0 +1,314 @@
+// Package ais provides core functionality for the AIStore object storage.
+/*
+ * Copyright (c) 2018-2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package ais
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/aistore/ais/s3"
+	"github.com/NVIDIA/aistore/cmn/nlog"
+)
+
+// Cross-cutting datapath request logging: wraps the dpq.parse call (and the
+// rest of a request's lifecycle) to emit one structured record per request,
+// with head/tail sampling so a busy cluster doesn't log every successful
+// GET, plus an optional reproducer dump of each request's method, path,
+// query and headers (signature and credential fields redacted) for offline
+// replay.
+const (
+	QparamRequestID = "rid"              // propagated back as HeaderRequestID once assigned
+	HeaderRequestID = "X-Ais-Request-Id"
+)
+
+type (
+	// reqLogPhases breaks a request's latency down by where the time went -
+	// useful for telling "client is slow to send" apart from "backend is
+	// slow" apart from "network to a remote target is slow" without a
+	// packet capture.
+	reqLogPhases struct {
+		Parse   time.Duration `json:"parse_ns"`
+		Auth    time.Duration `json:"auth_ns"`
+		Backend time.Duration `json:"backend_ns"`
+		Network time.Duration `json:"network_ns"`
+	}
+
+	// ReqLogRecord is one structured log line for a single datapath request.
+	ReqLogRecord struct {
+		RequestID string        `json:"rid"`
+		Method    string        `json:"method"`
+		Bucket    string        `json:"bucket,omitempty"`
+		Object    string        `json:"object,omitempty"`
+		Dpq       string        `json:"dpq,omitempty"` // redacted, %+v of the resolved dpq
+		Status    int           `json:"status"`
+		BytesIn   int64         `json:"bytes_in"`
+		BytesOut  int64         `json:"bytes_out"`
+		Hops      int           `json:"hops"` // number of target hops (redirects) this request took
+		Latency   time.Duration `json:"latency_ns"`
+		Phases    reqLogPhases  `json:"phases"`
+		Err       string        `json:"error,omitempty"`
+	}
+
+	// ReqLogCtx accumulates one request's timing and counters as it moves
+	// through the datapath; call NewReqLogCtx at the point dpq.parse is
+	// invoked, record each phase as it completes, and call Finish once the
+	// response status is known.
+	ReqLogCtx struct {
+		rec       ReqLogRecord
+		start     time.Time
+		phaseFrom time.Time
+	}
+
+	// ReqLog owns sampling policy and, optionally, the reproducer dumper;
+	// one instance is meant to be shared across all datapath handlers.
+	ReqLog struct {
+		successSamplePct int // 0-100; errors are always logged regardless of this
+		dump             *reproducerDumper
+	}
+)
+
+// NewReqLog constructs a ReqLog that logs every error and successSamplePct%
+// of successful requests. dumpPath == "" disables reproducer-mode dumping.
+func NewReqLog(successSamplePct int, dumpPath string, dumpMaxSize int64) (*ReqLog, error) {
+	l := &ReqLog{successSamplePct: successSamplePct}
+	if dumpPath != "" {
+		d, err := newReproducerDumper(dumpPath, dumpMaxSize)
+		if err != nil {
+			return nil, err
+		}
+		l.dump = d
+	}
+	return l, nil
+}
+
+// NewRequestID generates a short, URL-safe request ID for QparamRequestID /
+// HeaderRequestID when the caller didn't already propagate one (e.g. from a
+// redirecting proxy hop).
+func NewRequestID() string {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// crypto/rand failing is effectively unrecoverable (exhausted
+		// entropy source); fall back to a time-based ID rather than erroring
+		// out of the datapath over a logging concern.
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// NewReqLogCtx starts a record for one request and, in reproducer mode,
+// dumps it. r itself is not modified: only the dumped copy of its query and
+// headers is redacted.
+func (l *ReqLog) NewReqLogCtx(r *http.Request, requestID string) *ReqLogCtx {
+	now := time.Now()
+	if l.dump != nil {
+		l.dump.dump(r, requestID) // best-effort; a dump failure must never fail the request
+	}
+	return &ReqLogCtx{
+		rec:       ReqLogRecord{RequestID: requestID, Method: r.Method},
+		start:     now,
+		phaseFrom: now,
+	}
+}
+
+// EndPhase records the duration of the phase ending now (parse, auth,
+// backend, or network) and starts the clock for whichever phase is next.
+func (c *ReqLogCtx) EndPhase(phase string) {
+	now := time.Now()
+	d := now.Sub(c.phaseFrom)
+	c.phaseFrom = now
+	switch phase {
+	case "parse":
+		c.rec.Phases.Parse = d
+	case "auth":
+		c.rec.Phases.Auth = d
+	case "backend":
+		c.rec.Phases.Backend = d
+	case "network":
+		c.rec.Phases.Network = d
+	}
+}
+
+// SetBucketObject and SetDpq let the caller backfill request-identifying
+// fields as they're resolved, rather than threading them through every
+// phase's call signature.
+func (c *ReqLogCtx) SetBucketObject(bucket, object string) { c.rec.Bucket, c.rec.Object = bucket, object }
+
+func (c *ReqLogCtx) SetDpq(d *dpq) { c.rec.Dpq = redactDpq(d) }
+
+func (c *ReqLogCtx) AddBytes(in, out int64) { c.rec.BytesIn += in; c.rec.BytesOut += out }
+
+func (c *ReqLogCtx) AddHop() { c.rec.Hops++ }
+
+// Finish closes out the record with the final response status (and, for
+// errors, the error that produced it) and - subject to sampling - writes the
+// structured log line.
+func (l *ReqLog) Finish(c *ReqLogCtx, status int, err error) {
+	c.rec.Status = status
+	c.rec.Latency = time.Since(c.start)
+	if err != nil {
+		c.rec.Err = err.Error()
+	}
+	if !l.shouldLog(status) {
+		return
+	}
+	b, jerr := json.Marshal(&c.rec)
+	if jerr != nil {
+		nlog.Errorln("reqlog: marshal:", jerr)
+		return
+	}
+	nlog.Infoln(string(b))
+}
+
+// shouldLog implements head/tail sampling: every non-2xx response is logged
+// (that's the "storm" case operators actually need), successes are sampled
+// at successSamplePct%.
+func (l *ReqLog) shouldLog(status int) bool {
+	if status == 0 || status >= 300 {
+		return true
+	}
+	if l.successSamplePct <= 0 {
+		return false
+	}
+	if l.successSamplePct >= 100 {
+		return true
+	}
+	return rand.IntN(100) < l.successSamplePct
+}
+
+// redactDpq renders a dpq's resolved fields for logging with every
+// signature/credential-bearing field blanked out, so a presigned URL's
+// secret-derived signature never ends up in a log file.
+func redactDpq(d *dpq) string {
+	cp := *d
+	if !cp.presign.Empty() {
+		cp.presign.Signature = "<redacted>"
+		cp.presign.Credential = "<redacted>"
+		cp.presign.AccessKeyID = "<redacted>"
+	}
+	return fmt.Sprintf("%+v", cp)
+}
+
+// redactHeaders returns a shallow copy of h with auth-bearing headers
+// blanked out, for use by the reproducer dumper (and anywhere else a raw
+// header set might get written to a file or shipped off-box).
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range []string{"Authorization", s3.HeaderSignature, s3.HeaderCredentials, "X-Amz-Security-Token"} {
+		if out.Get(name) != "" {
+			out.Set(name, "<redacted>")
+		}
+	}
+	return out
+}
+
+// redactQuery returns rawQuery with X-Amz-Signature/X-Amz-Credential (and
+// their lowercase query-param equivalents) blanked out.
+func redactQuery(rawQuery string) string {
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "<unparseable query, not dumped>"
+	}
+	for _, key := range []string{s3.QparamSignature, s3.HeaderSignature, s3.HeaderCredentials, s3.QparamAccessKeyID} {
+		if q.Has(key) {
+			q.Set(key, "<redacted>")
+		}
+	}
+	return q.Encode()
+}
+
+//
+// reproducer dump mode: best-effort, size-rotated raw request dump for
+// offline replay against a dev cluster.
+//
+
+type reproducerDumper struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	f       *os.File
+	size    int64
+}
+
+func newReproducerDumper(path string, maxSize int64) (*reproducerDumper, error) {
+	d := &reproducerDumper{path: path, maxSize: maxSize}
+	if err := d.open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *reproducerDumper) open() error {
+	f, err := os.OpenFile(d.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	d.f, d.size = f, fi.Size()
+	return nil
+}
+
+type reproducerRecord struct {
+	RequestID string      `json:"rid"`
+	Method    string      `json:"method"`
+	URL       string      `json:"url"`
+	Query     string      `json:"query"`
+	Headers   http.Header `json:"headers"`
+	Time      time.Time   `json:"time"`
+}
+
+func (d *reproducerDumper) dump(r *http.Request, requestID string) {
+	rec := reproducerRecord{
+		RequestID: requestID,
+		Method:    r.Method,
+		URL:       r.URL.Path,
+		Query:     redactQuery(r.URL.RawQuery),
+		Headers:   redactHeaders(r.Header),
+		Time:      time.Now(),
+	}
+	b, err := json.Marshal(&rec)
+	if err != nil {
+		nlog.Errorln("reqlog: reproducer marshal:", err)
+		return
+	}
+	b = append(b, '\n')
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.maxSize > 0 && d.size+int64(len(b)) > d.maxSize {
+		if err := d.rotateLocked(); err != nil {
+			nlog.Errorln("reqlog: reproducer rotate:", err)
+			return
+		}
+	}
+	n, err := d.f.Write(b)
+	if err != nil {
+		nlog.Errorln("reqlog: reproducer write:", err)
+		return
+	}
+	d.size += int64(n)
+}
+
+func (d *reproducerDumper) rotateLocked() error {
+	if d.f != nil {
+		d.f.Close()
+	}
+	rotated := fmt.Sprintf("%s.%d", d.path, time.Now().UnixNano())
+	if err := os.Rename(d.path, rotated); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return d.open()
+}
@@ -0,0 +1,94 @@
+// Package ais provides core functionality for the AIStore object storage.
+/*
+ * Copyright (c) 2018-2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package ais
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NVIDIA/aistore/ais/s3"
+)
+
+func TestReqLogShouldLogAlwaysLogsErrors(t *testing.T) {
+	l := &ReqLog{successSamplePct: 0}
+	if !l.shouldLog(500) {
+		t.Error("expected 5xx to always be logged regardless of sampling")
+	}
+	if !l.shouldLog(404) {
+		t.Error("expected 4xx to always be logged regardless of sampling")
+	}
+	if l.shouldLog(200) {
+		t.Error("expected 0%% sampling to drop successes")
+	}
+}
+
+func TestReqLogShouldLogFullSample(t *testing.T) {
+	l := &ReqLog{successSamplePct: 100}
+	for i := 0; i < 20; i++ {
+		if !l.shouldLog(200) {
+			t.Fatal("expected 100% sampling to always log successes")
+		}
+	}
+}
+
+func TestRedactDpqHidesPresignedSignature(t *testing.T) {
+	d := &dpq{}
+	d.presign.Signature = "super-secret-sig"
+	d.presign.Credential = "AKIAEXAMPLE/20240102/us-east-1/s3/aws4_request"
+	out := redactDpq(d)
+	if strings.Contains(out, "super-secret-sig") {
+		t.Errorf("signature leaked into redacted dpq: %s", out)
+	}
+	if strings.Contains(out, "AKIAEXAMPLE") {
+		t.Errorf("credential leaked into redacted dpq: %s", out)
+	}
+}
+
+func TestRedactHeadersHidesAuthorization(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/b/o", http.NoBody)
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=secret")
+	out := redactHeaders(req.Header)
+	if out.Get("Authorization") == req.Header.Get("Authorization") {
+		t.Error("expected Authorization header to be redacted")
+	}
+}
+
+func TestRedactQueryHidesSignature(t *testing.T) {
+	raw := s3.QparamSignature + "=deadbeef&other=1"
+	out := redactQuery(raw)
+	if strings.Contains(out, "deadbeef") {
+		t.Errorf("signature leaked into redacted query: %s", out)
+	}
+	if !strings.Contains(out, "other=1") {
+		t.Errorf("expected unrelated params to survive redaction: %s", out)
+	}
+}
+
+func TestReproducerDumperRotatesOnSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reproducer.log")
+
+	d, err := newReproducerDumper(path, 64 /* bytes, forces rotation almost immediately */)
+	if err != nil {
+		t.Fatalf("newReproducerDumper: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mybucket/myobj?a=1", http.NoBody)
+	for i := 0; i < 5; i++ {
+		d.dump(req, "rid-1")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) < 2 {
+		t.Errorf("expected at least one rotated file alongside %s, got %d entries", path, len(entries))
+	}
+}
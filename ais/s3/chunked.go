@@ -0,0 +1,177 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/NVIDIA/aistore/sigv4"
+)
+
+// Streaming signed payloads: aws-sdk-java and aws-sdk-go-v2 default to
+// chunked, per-chunk-signed PUT bodies rather than a single Authorization (or
+// presigned) signature over the whole object. The client advertises this with:
+//
+//	Content-Encoding: aws-chunked
+//	X-Amz-Content-Sha256: STREAMING-AWS4-HMAC-SHA256-PAYLOAD
+//	Content-Length: <framed size, NOT the object size>
+//
+// and the body is a sequence of frames:
+//
+//	<hex-chunk-size>;chunk-signature=<hex-sig>\r\n<chunk-data>\r\n
+//
+// terminated by a zero-size frame. Each chunk's signature is computed over a
+// "seed" that chains to the previous chunk's (verified) signature, so the
+// frames can't be reordered or truncated without detection.
+const (
+	HeaderContentEncoding = "Content-Encoding"
+	AWSChunkedEncoding    = "aws-chunked"
+
+	HeaderContentSHA256            = "X-Amz-Content-Sha256"
+	StreamingSHA256Payload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	chunkedPayloadStringToSignAlgo = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// maxChunkSize bounds a single frame's declared size: the hex size header
+	// is read off the wire before its signature is verified, so an attacker
+	// can put an arbitrary value there - without a cap, something like
+	// "7fffffffffffffff" turns straight into a multi-exabyte make([]byte, ...)
+	// before any signature check ever runs. 8MiB comfortably covers every SDK
+	// we've seen (the aws-sdk defaults are in the 64KiB-8MiB range).
+	maxChunkSize = 8 << 20
+)
+
+// ErrChunkedSignatureMismatch is wrapped by every de-framing and signature
+// verification error ChunkedReader produces - per S3, both map to the same
+// client-facing error code.
+var ErrChunkedSignatureMismatch = errors.New("SignatureDoesNotMatch")
+
+// IsChunkedStreamingPayload reports whether r's body is framed as a
+// streaming, chunk-signed upload (see above), i.e. whether callers should
+// wrap r.Body in a ChunkedReader instead of handing it to the PUT pipeline
+// as-is.
+func IsChunkedStreamingPayload(r *http.Request) bool {
+	return r.Header.Get(HeaderContentSHA256) == StreamingSHA256Payload &&
+		strings.Contains(r.Header.Get(HeaderContentEncoding), AWSChunkedEncoding)
+}
+
+// ChunkedReader de-frames an aws-chunked streaming body, verifying each
+// chunk's signature against the rolling seed as it goes, and exposes the
+// true (un-framed) object size once fully consumed. Feed the result to the
+// existing PUT pipeline in place of the raw request body; Size() is only
+// meaningful after Read has returned io.EOF, since the framed Content-Length
+// never matches the decoded size.
+type ChunkedReader struct {
+	src        *bufio.Reader
+	signingKey []byte
+	scope      string // "<date>/<region>/<service>/aws4_request"
+	amzDate    string // full "20060102T150405Z", echoed into every chunk's string-to-sign
+	seedSig    string // previous (verified) chunk signature; primed with the request's own signature
+	pending    []byte
+	size       int64
+	done       bool
+}
+
+// NewChunkedReader wraps body (typically r.Body of a request for which
+// IsChunkedStreamingPayload is true). seedSignature is the signature that
+// already authenticated the request as a whole - the header-based
+// Authorization signature (see authnsrv.VerifySigV4) or the presigned-URL
+// signature (see VerifyPresigned) - which seeds the very first chunk's
+// rolling signature chain. date/region/service/secret are the same
+// credential-scope components used to verify that outer signature.
+func NewChunkedReader(body io.Reader, seedSignature, amzDate, date, region, service, secret string) *ChunkedReader {
+	return &ChunkedReader{
+		src:        bufio.NewReader(body),
+		signingKey: sigv4.DeriveSigningKey(secret, date, region, service),
+		scope:      fmt.Sprintf("%s/%s/%s/%s", date, region, service, presignSuffix),
+		amzDate:    amzDate,
+		seedSig:    seedSignature,
+	}
+}
+
+// Size returns the true, de-framed object size seen so far; valid once Read
+// has returned io.EOF.
+func (c *ChunkedReader) Size() int64 { return c.size }
+
+func (c *ChunkedReader) Read(p []byte) (int, error) {
+	for len(c.pending) == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *ChunkedReader) nextChunk() error {
+	line, err := c.src.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("%w: chunk header: %v", ErrChunkedSignatureMismatch, err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	sizeHex, sig, ok := strings.Cut(line, ";chunk-signature=")
+	if !ok || sig == "" {
+		return fmt.Errorf("%w: malformed chunk header %q", ErrChunkedSignatureMismatch, line)
+	}
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil || size < 0 {
+		return fmt.Errorf("%w: malformed chunk size %q", ErrChunkedSignatureMismatch, sizeHex)
+	}
+	if size > maxChunkSize {
+		return fmt.Errorf("%w: chunk size %d exceeds %d-byte limit", ErrChunkedSignatureMismatch, size, maxChunkSize)
+	}
+
+	data := make([]byte, size)
+	if size > 0 {
+		if _, err := io.ReadFull(c.src, data); err != nil {
+			return fmt.Errorf("%w: chunk data: %v", ErrChunkedSignatureMismatch, err)
+		}
+	}
+	if err := c.consumeCRLF(); err != nil {
+		return err
+	}
+
+	sts := strings.Join([]string{
+		chunkedPayloadStringToSignAlgo,
+		c.amzDate,
+		c.scope,
+		c.seedSig,
+		sigv4.SHA256Hex(""),
+		sigv4.SHA256Hex(string(data)),
+	}, "\n")
+	expected := hex.EncodeToString(sigv4.HMACSHA256(c.signingKey, sts))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(sig)) != 1 {
+		return fmt.Errorf("%w: chunk signature", ErrChunkedSignatureMismatch)
+	}
+	c.seedSig = expected
+
+	if size == 0 {
+		c.done = true
+		return nil
+	}
+	c.size += size
+	c.pending = data
+	return nil
+}
+
+func (c *ChunkedReader) consumeCRLF() error {
+	var crlf [2]byte
+	if _, err := io.ReadFull(c.src, crlf[:]); err != nil || crlf[0] != '\r' || crlf[1] != '\n' {
+		return fmt.Errorf("%w: missing chunk trailer", ErrChunkedSignatureMismatch)
+	}
+	return nil
+}
+
@@ -0,0 +1,224 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NVIDIA/aistore/cmn/debug"
+	"github.com/NVIDIA/aistore/cmn/nlog"
+	"github.com/NVIDIA/aistore/hk"
+)
+
+// Every InitUpload/AddPart/FinishUpload mutation of the in-memory `up` map is
+// additionally appended to a per-bucket, append-only journal so that a target
+// restart (or crash) between InitUpload and FinishUpload doesn't orphan
+// workfiles or leave `up` out of sync with what's actually on disk. The
+// journal is replayed at startup (see replayJournals, called from
+// SetJournalDir) and truncated once an upload reaches a terminal state
+// (FinishUpload, aborted or not) and no other upload of the same bucket is
+// still live - at that point the xattr written by storeMptXattr (or the removed
+// workfiles, on abort) is the durable record, and the journal entry is dead
+// weight.
+const (
+	journalExt       = ".mpt.log"
+	DefaultUploadTTL = 24 * time.Hour // janitor: abort uploads older than this
+)
+
+type (
+	journalOp string
+
+	// journalRec is one append-only line of a bucket's journal.
+	journalRec struct {
+		Op       journalOp `json:"op"`
+		UploadID string    `json:"id"`
+		Bck      string    `json:"bck"`
+		Obj      string    `json:"obj,omitempty"`
+		PartNum  int64     `json:"num,omitempty"`
+		Size     int64     `json:"size,omitempty"`
+		MD5      string    `json:"md5,omitempty"`
+		FQN      string    `json:"fqn,omitempty"`
+		Ctime    time.Time `json:"ctime"`
+	}
+)
+
+const (
+	jInit     journalOp = "init"
+	jAddPart  journalOp = "part"
+	jComplete journalOp = "complete"
+	jAbort    journalOp = "abort"
+)
+
+var (
+	journalDir string
+	uploadTTL  time.Duration // janitor: abort uploads older than this - see SetJournalDir
+)
+
+// Notify, when set (by the target's intra-cluster control-plane wiring),
+// is called on every lifecycle event so that ListUploads on any proxy can
+// return a merged, deduplicated view across targets, and CheckParts can
+// verify parts held on peer targets before completing an upload. The s3
+// package itself has no notion of Smap or the broadcast transport, hence
+// the hook rather than a direct dependency.
+var Notify func(op journalOp, uploadID, bckName, objName string)
+
+// SetJournalDir points the multipart-upload journal at a directory under one
+// of the target's mountpaths (a dedicated fs.WorkfileType content-dir) and
+// replays any journal left behind by a previous run. Must be called once,
+// before the first InitUpload, as part of target startup - after Init.
+// ttl <= 0 falls back to DefaultUploadTTL, same convention as
+// downloader.RetryConfig.maxAttempts.
+func SetJournalDir(dir string, ttl time.Duration) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		ttl = DefaultUploadTTL
+	}
+	journalDir = dir
+	uploadTTL = ttl
+	if err := replayJournals(); err != nil {
+		return err
+	}
+	hk.Reg("mpt-upload-janitor", janitorSweep, uploadTTL)
+	return nil
+}
+
+func journalPath(bckName string) string {
+	return filepath.Join(journalDir, bckName+journalExt)
+}
+
+func appendJournal(bckName string, rec *journalRec, fsync bool) error {
+	if journalDir == "" {
+		return nil // journaling disabled (e.g. in tests that never call SetJournalDir)
+	}
+	fh, err := os.OpenFile(journalPath(bckName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if _, err := fh.Write(b); err != nil {
+		return err
+	}
+	if fsync {
+		return fh.Sync()
+	}
+	return nil
+}
+
+// truncateJournal drops the bucket's journal once every upload it mentions
+// has reached a terminal state - called from FinishUpload.
+func truncateJournal(bckName string) {
+	if journalDir == "" {
+		return
+	}
+	if err := os.Truncate(journalPath(bckName), 0); err != nil && !os.IsNotExist(err) {
+		nlog.Errorln("mpt journal truncate", bckName, err)
+	}
+}
+
+// replayJournals rebuilds `up` from every *.mpt.log found under journalDir,
+// dropping any part whose FQN workfile no longer exists (e.g. the workfile
+// mountpath itself didn't survive the crash) rather than trusting stale state.
+func replayJournals() error {
+	up = make(uploads)
+	entries, err := os.ReadDir(journalDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) == "" || !isJournal(e.Name()) {
+			continue
+		}
+		if err := replayOne(filepath.Join(journalDir, e.Name())); err != nil {
+			nlog.Errorln("mpt journal replay", e.Name(), err)
+		}
+	}
+	for id, upload := range up {
+		live := upload.parts[:0]
+		for _, part := range upload.parts {
+			if _, err := os.Stat(part.FQN); err != nil {
+				nlog.Infoln("mpt journal replay: dropping missing part", id, part.Num, part.FQN)
+				continue
+			}
+			live = append(live, part)
+		}
+		upload.parts = live
+	}
+	return nil
+}
+
+func isJournal(name string) bool {
+	const suffix = journalExt
+	return len(name) > len(suffix) && name[len(name)-len(suffix):] == suffix
+}
+
+func replayOne(fqn string) error {
+	fh, err := os.Open(fqn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	sc := bufio.NewScanner(fh)
+	for sc.Scan() {
+		var rec journalRec
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			nlog.Errorln("mpt journal: corrupt record in", fqn, err)
+			continue
+		}
+		switch rec.Op {
+		case jInit:
+			up[rec.UploadID] = &mpt{
+				bckName: rec.Bck,
+				objName: rec.Obj,
+				parts:   make([]*MptPart, 0, iniCapParts),
+				ctime:   rec.Ctime,
+			}
+		case jAddPart:
+			if upload, ok := up[rec.UploadID]; ok {
+				upload.parts = append(upload.parts, &MptPart{MD5: rec.MD5, FQN: rec.FQN, Size: rec.Size, Num: rec.PartNum})
+			}
+		case jComplete, jAbort:
+			delete(up, rec.UploadID)
+		}
+	}
+	return sc.Err()
+}
+
+// janitorSweep aborts uploads older than uploadTTL (the value SetJournalDir
+// was called with, or DefaultUploadTTL); registered with the housekeeper via
+// SetJournalDir and re-armed every time it runs.
+func janitorSweep() time.Duration {
+	cutoff := time.Now().Add(-uploadTTL)
+	var stale []string
+	mu.RLock()
+	for id, upload := range up {
+		if upload.ctime.Before(cutoff) {
+			stale = append(stale, id)
+		}
+	}
+	mu.RUnlock()
+
+	for _, id := range stale {
+		nlog.Infoln("mpt janitor: aborting stale upload", id)
+		debug.Assert(id != "")
+		FinishUpload(id, "", true /*aborted*/)
+	}
+	return uploadTTL
+}
@@ -0,0 +1,102 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJournalReplayDropsMissingParts(t *testing.T) {
+	dir := t.TempDir()
+	journalDir = dir
+	defer func() { journalDir = "" }()
+
+	fqnOK := filepath.Join(dir, "part1")
+	if err := os.WriteFile(fqnOK, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fqnMissing := filepath.Join(dir, "part2") // deliberately never created
+
+	up = make(uploads)
+	if err := appendJournal("bck", &journalRec{Op: jInit, UploadID: "u1", Bck: "bck", Obj: "obj"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal("bck", &journalRec{Op: jAddPart, UploadID: "u1", Bck: "bck", PartNum: 1, FQN: fqnOK}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal("bck", &journalRec{Op: jAddPart, UploadID: "u1", Bck: "bck", PartNum: 2, FQN: fqnMissing}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replayJournals(); err != nil {
+		t.Fatal(err)
+	}
+
+	upload, ok := up["u1"]
+	if !ok {
+		t.Fatal("expected upload u1 to survive replay")
+	}
+	if len(upload.parts) != 1 || upload.parts[0].FQN != fqnOK {
+		t.Errorf("expected only the part with an existing FQN to survive, got %+v", upload.parts)
+	}
+}
+
+func TestFinishUploadKeepsJournalWhileSiblingUploadLive(t *testing.T) {
+	dir := t.TempDir()
+	journalDir = dir
+	defer func() { journalDir = "" }()
+
+	up = uploads{
+		"u1": &mpt{bckName: "bck"},
+		"u2": &mpt{bckName: "bck"},
+	}
+	if err := appendJournal("bck", &journalRec{Op: jInit, UploadID: "u1", Bck: "bck"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal("bck", &journalRec{Op: jInit, UploadID: "u2", Bck: "bck"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	FinishUpload("u1", "", true /*aborted*/)
+	b, err := os.ReadFile(journalPath("bck"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) == 0 {
+		t.Fatal("journal was truncated while u2 is still live - its jInit record is now lost")
+	}
+
+	FinishUpload("u2", "", true /*aborted*/)
+	b, err = os.ReadFile(journalPath("bck"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != 0 {
+		t.Errorf("expected journal to be truncated once the last upload for the bucket finished, got %d bytes", len(b))
+	}
+}
+
+func TestJournalReplaySkipsCompletedUploads(t *testing.T) {
+	dir := t.TempDir()
+	journalDir = dir
+	defer func() { journalDir = "" }()
+
+	up = make(uploads)
+	if err := appendJournal("bck", &journalRec{Op: jInit, UploadID: "u2", Bck: "bck", Obj: "obj"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendJournal("bck", &journalRec{Op: jComplete, UploadID: "u2", Bck: "bck", Obj: "obj"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replayJournals(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := up["u2"]; ok {
+		t.Error("expected a completed upload to not reappear after replay")
+	}
+}
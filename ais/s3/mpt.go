@@ -14,15 +14,17 @@ import (
 
 	"github.com/NVIDIA/aistore/cluster"
 	"github.com/NVIDIA/aistore/cmn/debug"
+	"github.com/NVIDIA/aistore/cmn/nlog"
 )
 
 // NOTE: xattr stores only the (*) marked attributes
 type (
 	MptPart struct {
-		MD5  string // MD5 of the part (*)
-		FQN  string // FQN of the corresponding workfile
-		Size int64  // part size in bytes (*)
-		Num  int64  // part number (*)
+		MD5         string // checksum of the part (*) - alg given by ChecksumAlg, MD5 when empty
+		FQN         string // FQN of the corresponding workfile
+		ChecksumAlg string // e.g., "sha1" for backends (B2) that checksum parts with something other than MD5
+		Size        int64  // part size in bytes (*)
+		Num         int64  // part number (*)
 	}
 	mpt struct {
 		bckName string
@@ -38,49 +40,84 @@ var (
 	mu sync.RWMutex
 )
 
+// Init sets up the in-memory upload table. Journaling (crash recovery across
+// target restarts) is enabled separately via SetJournalDir, once a target
+// mountpath is available to host the journal.
 func Init() { up = make(uploads) }
 
 // Start miltipart upload
 func InitUpload(id, bckName, objName string) {
+	ctime := time.Now()
 	mu.Lock()
 	up[id] = &mpt{
 		bckName: bckName,
 		objName: objName,
 		parts:   make([]*MptPart, 0, iniCapParts),
-		ctime:   time.Now(),
+		ctime:   ctime,
 	}
 	mu.Unlock()
+
+	if err := appendJournal(bckName, &journalRec{Op: jInit, UploadID: id, Bck: bckName, Obj: objName, Ctime: ctime}, false); err != nil {
+		nlog.Errorln("mpt journal: init", id, err)
+	}
+	if Notify != nil {
+		Notify(jInit, id, bckName, objName)
+	}
 }
 
 // Add part to an active upload.
 // Some clients may omit size and md5. Only partNum is must-have.
 // md5 and fqn is filled by a target after successful saving the data to a workfile.
 func AddPart(id string, npart *MptPart) (err error) {
+	var bckName string
 	mu.Lock()
 	upload, ok := up[id]
 	if !ok {
 		err = fmt.Errorf("upload %q not found (%s, %d)", id, npart.FQN, npart.Num)
 	} else {
 		upload.parts = append(upload.parts, npart)
+		bckName = upload.bckName
 	}
 	mu.Unlock()
-	return
+	if err != nil {
+		return err
+	}
+
+	rec := &journalRec{Op: jAddPart, UploadID: id, Bck: bckName, PartNum: npart.Num, Size: npart.Size, MD5: npart.MD5, FQN: npart.FQN}
+	if jerr := appendJournal(bckName, rec, true /*fsync*/); jerr != nil {
+		nlog.Errorln("mpt journal: add-part", id, npart.Num, jerr)
+	}
+	return nil
 }
 
+// CheckPeerParts, when set (by the same target control-plane wiring that
+// sets Notify), is asked for any part CheckParts can't find in this target's
+// own `up` table - i.e., a part that landed on a peer target because
+// multipart PUTs for this upload weren't all pinned to the same target. The
+// s3 package has no notion of Smap or the broadcast transport itself, hence
+// the hook, same rationale as Notify.
+var CheckPeerParts func(id, bckName string, partNum int64) (*MptPart, error)
+
 // TODO: compare non-zero sizes (note: s3cmd sends 0) and part.ETag as well, if specified
 func CheckParts(id string, parts []*PartInfo) ([]*MptPart, error) {
 	mu.RLock()
-	defer mu.RUnlock()
 	upload, ok := up[id]
 	if !ok {
+		mu.RUnlock()
 		return nil, fmt.Errorf("upload %q not found", id)
 	}
-	// first, check that all parts are present
-	var prev = int64(-1)
+	bckName := upload.bckName
+	// first, check that all parts are present locally, remembering any gaps
+	// to fill in from peer targets once the lock is released - CheckPeerParts
+	// may do a network round trip, which must never happen under mu.
+	var (
+		prev    = int64(-1)
+		missing []int64
+	)
 	for _, part := range parts {
 		debug.Assert(part.PartNumber > prev) // must ascend
 		if upload.getPart(part.PartNumber) == nil {
-			return nil, fmt.Errorf("upload %q: part %d not found", id, part.PartNumber)
+			missing = append(missing, part.PartNumber)
 		}
 		prev = part.PartNumber
 	}
@@ -89,6 +126,24 @@ func CheckParts(id string, parts []*PartInfo) ([]*MptPart, error) {
 	for _, part := range parts {
 		nparts = append(nparts, upload.getPart(part.PartNumber))
 	}
+	mu.RUnlock()
+
+	if len(missing) == 0 {
+		return nparts, nil
+	}
+	if CheckPeerParts == nil {
+		return nil, fmt.Errorf("upload %q: part %d not found", id, missing[0])
+	}
+	for i, part := range parts {
+		if nparts[i] != nil {
+			continue
+		}
+		peerPart, err := CheckPeerParts(id, bckName, part.PartNumber)
+		if err != nil || peerPart == nil {
+			return nil, fmt.Errorf("upload %q: part %d not found (local or peer): %v", id, part.PartNumber, err)
+		}
+		nparts[i] = peerPart
+	}
 	return nparts, nil
 }
 
@@ -133,8 +188,34 @@ func FinishUpload(id, fqn string, aborted bool) {
 	for _, part := range upload.parts {
 		_ = os.RemoveAll(part.FQN)
 	}
+	bckName, objName := upload.bckName, upload.objName
 	delete(up, id)
+	// the journal file is per-bucket, not per-upload, so it's only safe to
+	// truncate once this was the last upload still live for bckName - other-
+	// wise a concurrent upload B to the same bucket loses its still-in-flight
+	// jInit/jAddPart records the moment upload A finishes.
+	otherLive := false
+	for _, other := range up {
+		if other.bckName == bckName {
+			otherLive = true
+			break
+		}
+	}
 	mu.Unlock()
+
+	op := jComplete
+	if aborted {
+		op = jAbort
+	}
+	if err := appendJournal(bckName, &journalRec{Op: op, UploadID: id, Bck: bckName, Obj: objName}, true /*fsync*/); err != nil {
+		nlog.Errorln("mpt journal:", op, id, err)
+	}
+	if !otherLive {
+		truncateJournal(bckName)
+	}
+	if Notify != nil {
+		Notify(op, id, bckName, objName)
+	}
 }
 
 // Returns the info about active upload with ID
@@ -161,6 +242,10 @@ func UploadExists(id string) bool {
 	return ok
 }
 
+// ListUploads returns uploads known to this target only. A proxy merges and
+// dedupes the per-target responses into the cluster-wide view; the Notify
+// hook is what feeds that cross-target aggregation on the init/abort/complete
+// side.
 func ListUploads(bckName, idMarker string, maxUploads int) (result *ListMptUploadsResult) {
 	mu.RLock()
 	results := make([]UploadInfoResult, 0, len(up))
@@ -0,0 +1,53 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2018-2022, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import "testing"
+
+func TestCheckPartsAllLocal(t *testing.T) {
+	up = uploads{"u1": &mpt{bckName: "bck", parts: []*MptPart{{Num: 1, MD5: "a"}, {Num: 2, MD5: "b"}}}}
+	defer func() { up = nil }()
+
+	parts, err := CheckParts("u1", []*PartInfo{{PartNumber: 1}, {PartNumber: 2}})
+	if err != nil {
+		t.Fatalf("CheckParts: %v", err)
+	}
+	if len(parts) != 2 || parts[0].MD5 != "a" || parts[1].MD5 != "b" {
+		t.Errorf("CheckParts = %+v, want parts 1 and 2 in order", parts)
+	}
+}
+
+func TestCheckPartsFallsBackToPeer(t *testing.T) {
+	up = uploads{"u1": &mpt{bckName: "bck", parts: []*MptPart{{Num: 1, MD5: "a"}}}}
+	defer func() { up = nil }()
+
+	var gotBck string
+	var gotPartNum int64
+	CheckPeerParts = func(_, bckName string, partNum int64) (*MptPart, error) {
+		gotBck, gotPartNum = bckName, partNum
+		return &MptPart{Num: partNum, MD5: "peer"}, nil
+	}
+	defer func() { CheckPeerParts = nil }()
+
+	parts, err := CheckParts("u1", []*PartInfo{{PartNumber: 1}, {PartNumber: 2}})
+	if err != nil {
+		t.Fatalf("CheckParts: %v", err)
+	}
+	if gotBck != "bck" || gotPartNum != 2 {
+		t.Errorf("CheckPeerParts called with (%q, %d), want (\"bck\", 2)", gotBck, gotPartNum)
+	}
+	if len(parts) != 2 || parts[1].MD5 != "peer" {
+		t.Errorf("CheckParts = %+v, want part 2 filled in from the peer", parts)
+	}
+}
+
+func TestCheckPartsMissingWithNoPeerHook(t *testing.T) {
+	up = uploads{"u1": &mpt{bckName: "bck", parts: []*MptPart{{Num: 1, MD5: "a"}}}}
+	defer func() { up = nil }()
+
+	if _, err := CheckParts("u1", []*PartInfo{{PartNumber: 1}, {PartNumber: 2}}); err == nil {
+		t.Error("expected an error for a missing part with no CheckPeerParts hook set")
+	}
+}
@@ -0,0 +1,136 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NVIDIA/aistore/sigv4"
+)
+
+// PresignParams is the set of AWS SigV4 presigned-URL query parameters the
+// datapath's fast query parser (dpq, in the ais package) recognizes but -
+// until now - only tolerated without acting on. A presigned URL carries the
+// signature itself in the query string (QparamSignature or HeaderSignature)
+// rather than in an Authorization header, which is what lets S3 SDKs hand out
+// time-boxed, per-object GET/PUT URLs that need no header-based auth at all.
+type PresignParams struct {
+	Algorithm     string // HeaderAlgorithm: must be "AWS4-HMAC-SHA256"
+	Credential    string // HeaderCredentials: accessKeyID/date/region/service/aws4_request
+	Date          string // HeaderDate: X-Amz-Date, e.g. "20240102T150405Z"
+	Expires       string // HeaderExpires: seconds, relative to Date
+	SignedHeaders string // HeaderSignedHeaders: semicolon-separated, lowercase
+	Signature     string // QparamSignature or HeaderSignature
+	AccessKeyID   string // QparamAccessKeyID (redundant with Credential; some clients send both)
+}
+
+// Empty reports whether the request carried no presigned-URL parameters at
+// all, i.e. this is a plain, non-presigned request and VerifyPresigned
+// shouldn't be called.
+func (p *PresignParams) Empty() bool { return p.Signature == "" && p.Credential == "" }
+
+var (
+	ErrPresignNotSigV4  = errors.New("presigned URL: unsupported or missing X-Amz-Algorithm")
+	ErrPresignBadSig    = errors.New("presigned URL: signature does not match")
+	ErrPresignExpired   = errors.New("presigned URL: expired")
+	ErrPresignClockSkew = errors.New("presigned URL: X-Amz-Date is too far in the future")
+	ErrPresignNoSuchKey = errors.New("presigned URL: unknown access key")
+
+	maxPresignClockSkew = 15 * time.Minute
+)
+
+const (
+	presignAlgo    = "AWS4-HMAC-SHA256"
+	presignSuffix  = "aws4_request"
+	presignDateFmt = "20060102T150405Z"
+)
+
+// VerifyPresigned authenticates an AWS SigV4 presigned-URL request: it
+// reconstructs the canonical request from the method, path, sorted query
+// (less the signature itself), and the caller-designated signed headers,
+// derives the signing key from the secret lookupSecret returns for the
+// embedded access-key ID, and constant-time-compares the result against
+// p.Signature. It also enforces the X-Amz-Date/X-Amz-Expires validity
+// window and rejects requests whose X-Amz-Date is implausibly far in the
+// future (clock-skew/replay protection).
+//
+// lookupSecret decouples this package from wherever access keys actually
+// live (see authnsrv.AccessKey) - it returns the shared secret for a given
+// access-key ID, or ok=false if there's no such key.
+func VerifyPresigned(r *http.Request, p *PresignParams, lookupSecret func(accessKeyID string) (secret string, ok bool)) (accessKeyID string, _ error) {
+	if p.Algorithm != presignAlgo {
+		return "", ErrPresignNotSigV4
+	}
+	accessKeyID, date, region, service, err := parseCredential(p.Credential)
+	if err != nil {
+		return "", err
+	}
+	if p.AccessKeyID != "" && p.AccessKeyID != accessKeyID {
+		return "", fmt.Errorf("presigned URL: access key mismatch (%q vs credential's %q)", p.AccessKeyID, accessKeyID)
+	}
+
+	signedAt, err := time.Parse(presignDateFmt, p.Date)
+	if err != nil {
+		return "", fmt.Errorf("presigned URL: invalid X-Amz-Date: %w", err)
+	}
+	if signedAt.Format("20060102") != date {
+		return "", errors.New("presigned URL: X-Amz-Date does not match credential scope date")
+	}
+	if signedAt.After(time.Now().Add(maxPresignClockSkew)) {
+		return "", ErrPresignClockSkew
+	}
+	expSec, err := strconv.ParseInt(p.Expires, 10, 64)
+	if err != nil || expSec < 0 {
+		return "", fmt.Errorf("presigned URL: invalid X-Amz-Expires %q", p.Expires)
+	}
+	if time.Now().After(signedAt.Add(time.Duration(expSec) * time.Second)) {
+		return "", ErrPresignExpired
+	}
+
+	secret, ok := lookupSecret(accessKeyID)
+	if !ok {
+		return "", ErrPresignNoSuchKey
+	}
+
+	creq := canonicalPresignedRequest(r, p.SignedHeaders)
+	scope := fmt.Sprintf("%s/%s/%s/%s", date, region, service, presignSuffix)
+	sts := strings.Join([]string{presignAlgo, p.Date, scope, sigv4.SHA256Hex(creq)}, "\n")
+	signingKey := sigv4.DeriveSigningKey(secret, date, region, service)
+	expected := hex.EncodeToString(sigv4.HMACSHA256(signingKey, sts))
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(p.Signature)) != 1 {
+		return "", ErrPresignBadSig
+	}
+	return accessKeyID, nil
+}
+
+func parseCredential(cred string) (accessKeyID, date, region, service string, err error) {
+	parts := strings.Split(cred, "/")
+	if len(parts) != 5 || parts[4] != presignSuffix {
+		return "", "", "", "", fmt.Errorf("presigned URL: malformed X-Amz-Credential %q", cred)
+	}
+	return parts[0], parts[1], parts[2], parts[3], nil
+}
+
+// canonicalPresignedRequest mirrors the AWS SigV4 presigned-URL canonical
+// request (see sigv4.CanonicalRequest), the one difference from a
+// header-signed request being that the signature query parameter itself is
+// never part of what gets signed - the signature may arrive under either
+// QparamSignature or HeaderSignature (see dpq), and both are excluded below.
+func canonicalPresignedRequest(r *http.Request, signedHeaders string) string {
+	if q := r.URL.Query(); q.Has(HeaderSignature) {
+		q.Del(HeaderSignature)
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = q.Encode()
+	}
+	return sigv4.CanonicalRequest(r, signedHeaders, "UNSIGNED-PAYLOAD", QparamSignature)
+}
@@ -0,0 +1,91 @@
+// Package s3 provides Amazon S3 compatibility layer
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package s3
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/aistore/sigv4"
+)
+
+const testSecret = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+
+func signPresignedForTest(t *testing.T, req *http.Request, accessKeyID string, signedAt time.Time, expiresSec int) *PresignParams {
+	t.Helper()
+	date := signedAt.Format(presignDateFmt)
+	scope := date[:8] + "/us-east-1/s3/" + presignSuffix
+	p := &PresignParams{
+		Algorithm:     presignAlgo,
+		Credential:    accessKeyID + "/" + scope,
+		Date:          date,
+		Expires:       strconv.Itoa(expiresSec),
+		SignedHeaders: "host",
+	}
+	if req.Host == "" {
+		req.Host = "example.com"
+	}
+	creq := canonicalPresignedRequest(req, p.SignedHeaders)
+	stringToSign := presignAlgo + "\n" + date + "\n" + scope + "\n" + sigv4.SHA256Hex(creq)
+	key := sigv4.DeriveSigningKey(testSecret, date[:8], "us-east-1", "s3")
+	p.Signature = hex.EncodeToString(sigv4.HMACSHA256(key, stringToSign))
+	return p
+}
+
+func lookup(secret string) func(string) (string, bool) {
+	return func(accessKeyID string) (string, bool) {
+		if accessKeyID == "AKIAEXAMPLE" {
+			return secret, true
+		}
+		return "", false
+	}
+}
+
+func TestVerifyPresignedRoundTrip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mybucket/myobj", http.NoBody)
+	p := signPresignedForTest(t, req, "AKIAEXAMPLE", time.Now().UTC(), 900)
+
+	id, err := VerifyPresigned(req, p, lookup(testSecret))
+	if err != nil {
+		t.Fatalf("VerifyPresigned: %v", err)
+	}
+	if id != "AKIAEXAMPLE" {
+		t.Errorf("expected AKIAEXAMPLE, got %q", id)
+	}
+}
+
+func TestVerifyPresignedRejectsExpired(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mybucket/myobj", http.NoBody)
+	p := signPresignedForTest(t, req, "AKIAEXAMPLE", time.Now().UTC().Add(-time.Hour), 60) // expired 59m ago
+
+	if _, err := VerifyPresigned(req, p, lookup(testSecret)); err != ErrPresignExpired {
+		t.Errorf("expected ErrPresignExpired, got %v", err)
+	}
+}
+
+func TestVerifyPresignedRejectsTamperedPath(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mybucket/myobj", http.NoBody)
+	p := signPresignedForTest(t, req, "AKIAEXAMPLE", time.Now().UTC(), 900)
+
+	// tamper with the request after it was signed
+	req.URL.Path = "/mybucket/other-obj"
+
+	if _, err := VerifyPresigned(req, p, lookup(testSecret)); err != ErrPresignBadSig {
+		t.Errorf("expected ErrPresignBadSig, got %v", err)
+	}
+}
+
+func TestVerifyPresignedUnknownKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mybucket/myobj", http.NoBody)
+	p := signPresignedForTest(t, req, "AKIAUNKNOWN", time.Now().UTC(), 900)
+
+	if _, err := VerifyPresigned(req, p, lookup(testSecret)); err != ErrPresignNoSuchKey {
+		t.Errorf("expected ErrPresignNoSuchKey, got %v", err)
+	}
+}
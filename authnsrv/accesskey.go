@@ -0,0 +1,298 @@
+// Package authnsrv provides AuthN server for AIStore.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package authnsrv
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/aistore/api/apc"
+	"github.com/NVIDIA/aistore/api/authn"
+	"github.com/NVIDIA/aistore/sigv4"
+)
+
+// AccessKey is a long-lived (ID, Secret) pair S3-compatible clients (aws-cli,
+// boto3, s3cmd) can sign requests with via AWS SigV4, as an alternative to
+// passing a JWT. It's always minted off an existing Token (see
+// CreateAccessKey) and is subject to the very same Restrictions narrowing as
+// the scoped application tokens in utils.go - a key can be created with
+// read-only access to a single prefix of a single bucket. VerifySigV4 carries
+// that narrowing into the Token it returns, and Token.CheckPermissions
+// enforces it on every request.
+type AccessKey struct {
+	ID           string          `json:"access_key_id"`
+	Secret       string          `json:"secret_access_key"`
+	Owner        string          `json:"owner"` // Token.UserID this key was minted from
+	ClusterACLs  []*authn.CluACL `json:"clusters"`
+	BucketACLs   []*authn.BckACL `json:"buckets,omitempty"`
+	Restrictions *Restrictions   `json:"restrictions,omitempty"`
+	Expires      time.Time       `json:"expires"`
+	// SessionToken, when non-empty, makes this an STS-style temporary
+	// credential: callers must echo it back via the x-amz-security-token
+	// header, in addition to a valid SigV4 signature.
+	SessionToken string `json:"session_token,omitempty"`
+}
+
+var (
+	ErrAccessKeyNotFound = errors.New("access key not found")
+	ErrAccessKeyExpired  = errors.New("access key expired")
+	ErrBadSignature      = errors.New("signature does not match")
+	ErrMissingSessionTok = errors.New("missing x-amz-security-token for temporary credential")
+)
+
+var (
+	keys   = make(map[string]*AccessKey) // by AccessKeyID
+	keysMu sync.RWMutex
+)
+
+/////////////
+// mint/manage
+/////////////
+
+// CreateAccessKey mints a new AccessKey bound to parent's ACLs, narrowed by
+// r exactly as IssueScopedToken narrows a scoped application token: r.Caps is
+// AND-masked against what parent actually grants, and r.Buckets/r.Prefixes
+// (if any) travel with the Token VerifySigV4 returns, where
+// Token.CheckPermissions enforces them on every subsequent request.
+func CreateAccessKey(parent *Token, r Restrictions, ttl time.Duration) (*AccessKey, error) {
+	if parent == nil {
+		return nil, errors.New("cannot create an access key without a parent token")
+	}
+	r.Caps &= parentCaps(parent)
+	r.NotBefore = time.Now()
+
+	id, err := genAccessKeyID()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := genSecret()
+	if err != nil {
+		return nil, err
+	}
+	ak := &AccessKey{
+		ID:           id,
+		Secret:       secret,
+		Owner:        parent.UserID,
+		ClusterACLs:  parent.ClusterACLs,
+		BucketACLs:   parent.BucketACLs,
+		Restrictions: &r,
+		Expires:      r.NotBefore.Add(ttl),
+	}
+	keysMu.Lock()
+	keys[id] = ak
+	keysMu.Unlock()
+	return ak, nil
+}
+
+// ListAccessKeys returns every access key minted for owner (a Token.UserID).
+func ListAccessKeys(owner string) []*AccessKey {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	out := make([]*AccessKey, 0, len(keys))
+	for _, ak := range keys {
+		if ak.Owner == owner {
+			out = append(out, ak)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// RotateAccessKey replaces id's secret in place, keeping its ACLs,
+// Restrictions, and expiration untouched; the old secret stops verifying
+// immediately.
+func RotateAccessKey(id string) (*AccessKey, error) {
+	secret, err := genSecret()
+	if err != nil {
+		return nil, err
+	}
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	ak, ok := keys[id]
+	if !ok {
+		return nil, ErrAccessKeyNotFound
+	}
+	ak.Secret = secret
+	return ak, nil
+}
+
+func RevokeAccessKey(id string) error {
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	if _, ok := keys[id]; !ok {
+		return ErrAccessKeyNotFound
+	}
+	delete(keys, id)
+	return nil
+}
+
+// parentCaps computes the full set of capabilities a token actually grants,
+// the same way IssueScopedToken does, so CreateAccessKey can never mint a
+// key with more access than its parent token has.
+func parentCaps(parent *Token) apc.AccessAttrs {
+	if parent.IsAdmin {
+		return apc.AccessAll
+	}
+	var caps apc.AccessAttrs
+	for _, acl := range parent.ClusterACLs {
+		caps |= acl.Access
+	}
+	for _, acl := range parent.BucketACLs {
+		caps |= acl.Access
+	}
+	return caps
+}
+
+func genAccessKeyID() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "AKIA" + strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+func genSecret() (string, error) {
+	b := make([]byte, 30)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+/////////////
+// SigV4 verification
+/////////////
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	sigV4Suffix    = "aws4_request"
+	amzDateHdr     = "X-Amz-Date"
+	amzContentSHA  = "X-Amz-Content-Sha256"
+	amzSecTokenHdr = "X-Amz-Security-Token"
+	unsignedPload  = "UNSIGNED-PAYLOAD"
+	amzDateFormat  = "20060102T150405Z"
+)
+
+// VerifySigV4 authenticates req against the AccessKeyID embedded in its
+// "Authorization: AWS4-HMAC-SHA256 Credential=..." header: it recomputes the
+// canonical request, the string-to-sign, and the derived signing key exactly
+// as the AWS SigV4 spec defines them, and compares the result against the
+// signature the client sent. On success it returns a Token synthesized from
+// the matched AccessKey's ACLs/Restrictions - the same Token type
+// CheckPermissions already consumes for JWT-authenticated requests.
+func VerifySigV4(req *http.Request, region, service string) (*Token, error) {
+	auth := req.Header.Get("Authorization")
+	cred, signedHeaders, signature, err := parseSigV4Auth(auth)
+	if err != nil {
+		return nil, err
+	}
+	accessKeyID, date, credRegion, credService, err := parseCredentialScope(cred)
+	if err != nil {
+		return nil, err
+	}
+
+	keysMu.RLock()
+	ak, ok := keys[accessKeyID]
+	keysMu.RUnlock()
+	if !ok {
+		return nil, ErrAccessKeyNotFound
+	}
+	if time.Now().After(ak.Expires) {
+		return nil, ErrAccessKeyExpired
+	}
+	if ak.SessionToken != "" && req.Header.Get(amzSecTokenHdr) != ak.SessionToken {
+		return nil, ErrMissingSessionTok
+	}
+
+	amzDate := req.Header.Get(amzDateHdr)
+	if amzDate == "" {
+		return nil, errors.New("missing " + amzDateHdr)
+	}
+	ts, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", amzDateHdr, err)
+	}
+	if ts.Format("20060102") != date {
+		return nil, errors.New("request date does not match credential scope")
+	}
+
+	creq := canonicalRequest(req, signedHeaders)
+	scope := fmt.Sprintf("%s/%s/%s/%s", date, credRegion, credService, sigV4Suffix)
+	sts := stringToSign(amzDate, scope, creq)
+	signingKey := sigv4.DeriveSigningKey(ak.Secret, date, credRegion, credService)
+	expected := hex.EncodeToString(sigv4.HMACSHA256(signingKey, sts))
+
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) != 1 {
+		return nil, ErrBadSignature
+	}
+	if credRegion != region || credService != service {
+		return nil, fmt.Errorf("%w: credential scope %s/%s does not match %s/%s",
+			ErrBadSignature, credRegion, credService, region, service)
+	}
+
+	return &Token{
+		UserID:       ak.Owner,
+		Expires:      ak.Expires,
+		ClusterACLs:  ak.ClusterACLs,
+		BucketACLs:   ak.BucketACLs,
+		Restrictions: ak.Restrictions,
+	}, nil
+}
+
+// parseSigV4Auth splits the Authorization header into its three comma-
+// separated components: Credential=.../SignedHeaders=.../Signature=...
+func parseSigV4Auth(auth string) (cred, signedHeaders, signature string, err error) {
+	const prefix = sigV4Algorithm + " "
+	if !strings.HasPrefix(auth, prefix) {
+		return "", "", "", errors.New("missing or unsupported Authorization scheme")
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(auth, prefix), ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case strings.HasPrefix(part, "Credential="):
+			cred = strings.TrimPrefix(part, "Credential=")
+		case strings.HasPrefix(part, "SignedHeaders="):
+			signedHeaders = strings.TrimPrefix(part, "SignedHeaders=")
+		case strings.HasPrefix(part, "Signature="):
+			signature = strings.TrimPrefix(part, "Signature=")
+		}
+	}
+	if cred == "" || signedHeaders == "" || signature == "" {
+		return "", "", "", errors.New("malformed Authorization header")
+	}
+	return cred, signedHeaders, signature, nil
+}
+
+func parseCredentialScope(cred string) (accessKeyID, date, region, service string, err error) {
+	parts := strings.Split(cred, "/")
+	if len(parts) != 5 || parts[4] != sigV4Suffix {
+		return "", "", "", "", fmt.Errorf("malformed credential scope %q", cred)
+	}
+	return parts[0], parts[1], parts[2], parts[3], nil
+}
+
+// canonicalRequest builds the SigV4 canonical request (see sigv4.CanonicalRequest):
+// a header-signed request has no query-string exclusion, unlike a presigned
+// URL (ais/s3.canonicalPresignedRequest), whose signature lives in the query
+// string itself.
+func canonicalRequest(req *http.Request, signedHeaders string) string {
+	payloadHash := req.Header.Get(amzContentSHA)
+	if payloadHash == "" {
+		payloadHash = unsignedPload
+	}
+	return sigv4.CanonicalRequest(req, signedHeaders, payloadHash, "")
+}
+
+func stringToSign(amzDate, scope, canonicalReq string) string {
+	return strings.Join([]string{sigV4Algorithm, amzDate, scope, sigv4.SHA256Hex(canonicalReq)}, "\n")
+}
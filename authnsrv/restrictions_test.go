@@ -0,0 +1,108 @@
+// Package authnsrv provides AuthN server for AIStore.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package authnsrv
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NVIDIA/aistore/api/apc"
+	"github.com/NVIDIA/aistore/api/authn"
+	"github.com/NVIDIA/aistore/cmn"
+)
+
+func TestRestrictionsPrefixBypass(t *testing.T) {
+	bck := cmn.Bck{Name: "bucket", Provider: cmn.ProviderAIS}
+	r := &Restrictions{Prefixes: map[string]string{bck.String(): "public/"}}
+
+	tests := []struct {
+		objName string
+		wantErr bool
+	}{
+		{"public/obj.txt", false},
+		{"public/nested/obj.txt", false},
+		{"private/obj.txt", true},
+		{"public", true},           // missing trailing slash: not under the prefix
+		{"public/../secret", true}, // path-traversal attempt
+		{"", true},                 // empty object name is never "public/"-prefixed
+	}
+	for _, tc := range tests {
+		err := r.check(&bck, tc.objName)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("check(%q): got err=%v, wantErr=%v", tc.objName, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRestrictionsPrefixNonDirectoryShaped(t *testing.T) {
+	bck := cmn.Bck{Name: "bucket", Provider: cmn.ProviderAIS}
+	// not every restriction prefix is a directory: "2024-" is meant to match
+	// "2024-01.jpg", "2024-02.jpg", etc. without requiring a "/" boundary.
+	r := &Restrictions{Prefixes: map[string]string{bck.String(): "2024-"}}
+
+	tests := []struct {
+		objName string
+		wantErr bool
+	}{
+		{"2024-01.jpg", false},
+		{"2024-02.jpg", false},
+		{"2023-01.jpg", true},
+		{"2024-01/../../../etc/passwd", true}, // still rejected: escapes past the prefix
+	}
+	for _, tc := range tests {
+		err := r.check(&bck, tc.objName)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("check(%q): got err=%v, wantErr=%v", tc.objName, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRestrictionsBucketAllowList(t *testing.T) {
+	allowed := cmn.Bck{Name: "allowed", Provider: cmn.ProviderAIS}
+	other := cmn.Bck{Name: "other", Provider: cmn.ProviderAIS}
+	r := &Restrictions{Buckets: []cmn.Bck{allowed}}
+
+	if err := r.check(&allowed, "x"); err != nil {
+		t.Errorf("expected allowed bucket to pass, got %v", err)
+	}
+	if err := r.check(&other, "x"); err == nil {
+		t.Error("expected bucket outside allow-list to be rejected")
+	}
+}
+
+func TestCheckPermissionsCapabilityEscalation(t *testing.T) {
+	bck := cmn.Bck{Name: "bucket", Provider: cmn.ProviderAIS}
+	tk := &Token{
+		UserID:     "scoped-user",
+		IsAdmin:    false, // scoped tokens must never be admin
+		BucketACLs: []*authn.BckACL{{Bck: bck, Access: apc.AccessAll}},
+		Restrictions: &Restrictions{
+			Buckets: []cmn.Bck{bck},
+			Caps:    apc.AccessGET, // read-only, even though the underlying ACL grants everything
+		},
+	}
+
+	if err := tk.CheckPermissions("", &bck, "obj", apc.AccessGET); err != nil {
+		t.Errorf("expected masked read access to be permitted, got %v", err)
+	}
+	if err := tk.CheckPermissions("", &bck, "obj", apc.AccessPUT); err == nil {
+		t.Error("expected write access to be rejected by the capability mask, even though the ACL grants it")
+	}
+}
+
+func TestIssueScopedTokenNeverElevatesToAdmin(t *testing.T) {
+	parent := &Token{UserID: "admin-user", IsAdmin: true}
+	tokenStr, err := IssueScopedToken(parent, Restrictions{Caps: apc.AccessAll}, time.Hour, "test-secret")
+	if err != nil {
+		t.Fatalf("IssueScopedToken failed: %v", err)
+	}
+	tk, err := DecryptToken(tokenStr, "test-secret")
+	if err != nil {
+		t.Fatalf("DecryptToken failed: %v", err)
+	}
+	if tk.IsAdmin {
+		t.Error("scoped token must never carry IsAdmin=true, regardless of the parent")
+	}
+}
@@ -7,6 +7,8 @@ package authnsrv
 import (
 	"errors"
 	"fmt"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/NVIDIA/aistore/api/apc"
@@ -24,6 +26,23 @@ type (
 		ClusterACLs []*authn.CluACL `json:"clusters"`
 		BucketACLs  []*authn.BckACL `json:"buckets,omitempty"`
 		IsAdmin     bool            `json:"admin"`
+		// Restrictions, when non-nil, scopes down everything this token's
+		// parent (admin/user) token grants - it can only narrow permissions,
+		// never widen them. Present on application tokens minted via
+		// IssueScopedToken; nil on ordinary user/admin tokens.
+		Restrictions *Restrictions `json:"restrictions,omitempty"`
+	}
+
+	// Restrictions models a capability, modeled on B2 application keys: a
+	// bucket allow-list, an optional object-name prefix per bucket, a
+	// capability bit-set that AND-masks the parent's apc.AccessAttrs, and a
+	// validity window.
+	Restrictions struct {
+		Buckets   []cmn.Bck         `json:"buckets,omitempty"`  // empty => all buckets the parent can already access
+		Prefixes  map[string]string `json:"prefixes,omitempty"` // bucket.String() -> required object-name prefix
+		Caps      apc.AccessAttrs   `json:"caps"`               // AND-masked against the parent's effective perms
+		NotBefore time.Time         `json:"not_before"`
+		ParentJTI string            `json:"parent_jti"`
 	}
 )
 
@@ -97,15 +116,36 @@ func (tk *Token) aclForBucket(clusterID string, bck *cmn.Bck) (perms apc.AccessA
 // a user do not have permissions for the given `clusterID`.
 //
 // ACL rules are checked in the following order (from highest to the lowest priority):
-//   1. A user's role is an admin.
-//   2. User's permissions for the given bucket
-//   3. User's permissions for the given cluster
-//   4. User's default cluster permissions (ACL for a cluster with empty clusterID)
+//  1. A user's role is an admin.
+//  2. User's permissions for the given bucket
+//  3. User's permissions for the given cluster
+//  4. User's default cluster permissions (ACL for a cluster with empty clusterID)
+//
 // If there are no defined ACL found at any step, any access is denied.
-func (tk *Token) CheckPermissions(clusterID string, bck *cmn.Bck, perms apc.AccessAttrs) error {
+//
+// When tk.Restrictions is set (scoped application tokens, see
+// IssueScopedToken), it is applied on top of the above: IsAdmin is never
+// honored, requested perms outside Restrictions.Caps are rejected, and the
+// bucket/object-prefix allow-list is enforced before any ACL is consulted.
+func (tk *Token) CheckPermissions(clusterID string, bck *cmn.Bck, objName string, perms apc.AccessAttrs) error {
+	if tk.Restrictions != nil {
+		if err := tk.Restrictions.check(bck, objName); err != nil {
+			return err
+		}
+		// a restricted token can never elevate to admin nor exceed its capability mask,
+		// regardless of what the parent token it was derived from grants
+		if perms&^tk.Restrictions.Caps != 0 {
+			return fmt.Errorf("%v: [%s, requested permissions exceed the token's capabilities]", ErrNoPermissions, tk)
+		}
+		return tk.checkPermissionsACL(clusterID, bck, perms)
+	}
 	if tk.IsAdmin {
 		return nil
 	}
+	return tk.checkPermissionsACL(clusterID, bck, perms)
+}
+
+func (tk *Token) checkPermissionsACL(clusterID string, bck *cmn.Bck, perms apc.AccessAttrs) error {
 	if perms == 0 {
 		return errors.New("Empty permissions requested")
 	}
@@ -145,6 +185,102 @@ func (tk *Token) CheckPermissions(clusterID string, bck *cmn.Bck, perms apc.Acce
 	return nil
 }
 
+/////////////////
+// Restrictions //
+/////////////////
+
+// check enforces the bucket allow-list and the per-bucket object-name prefix.
+// An empty Buckets list means "whatever the parent ACLs allow"; a present but
+// empty prefix for a bucket means "whole bucket". objName is attacker
+// controlled (it comes straight off the request), so a plain HasPrefix isn't
+// enough on its own: "public/../secret" satisfies HasPrefix(objName,
+// "public/") while actually resolving outside "public/" - objName is
+// therefore also path.Clean-ed and re-checked against the cleaned prefix to
+// catch any ".." segment that walks back out.
+func (r *Restrictions) check(bck *cmn.Bck, objName string) error {
+	if len(r.Buckets) > 0 {
+		found := false
+		for i := range r.Buckets {
+			if r.Buckets[i].Equal(bck) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%v: bucket %s not in token's restricted bucket list", ErrNoPermissions, bck)
+		}
+	}
+	if len(r.Prefixes) == 0 || bck == nil {
+		return nil
+	}
+	prefix, ok := r.Prefixes[bck.String()]
+	if !ok || prefix == "" {
+		return nil // no prefix restriction for this bucket
+	}
+	if strings.Contains(prefix, "..") {
+		return fmt.Errorf("%v: invalid restricted prefix %q", ErrNoPermissions, prefix)
+	}
+	if !strings.HasPrefix(objName, prefix) {
+		return fmt.Errorf("%v: object %q is outside restricted prefix %q", ErrNoPermissions, objName, prefix)
+	}
+	// objName passed the raw HasPrefix check above, but that alone doesn't
+	// catch a ".." segment walking back out of prefix (e.g. objName
+	// "public/../secret" with prefix "public/") - clean both and re-check
+	// that the cleaned object still starts with the cleaned prefix. For a
+	// prefix that ends in "/", path.Clean drops that "/", so the boundary is
+	// put back explicitly ("public/../publicity" must not pass as "public/").
+	// Any other prefix is compared as a plain string prefix: a restriction
+	// prefix isn't necessarily directory-shaped (e.g. "2024-" matches
+	// "2024-01.jpg", "2024-02.jpg", ...).
+	cleanPrefix := path.Clean(prefix)
+	cleanObj := path.Clean(objName)
+	inside := strings.HasPrefix(cleanObj, cleanPrefix)
+	if strings.HasSuffix(prefix, "/") {
+		inside = cleanObj == cleanPrefix || strings.HasPrefix(cleanObj, strings.TrimSuffix(cleanPrefix, "/")+"/")
+	}
+	if !inside {
+		return fmt.Errorf("%v: object %q escapes restricted prefix %q", ErrNoPermissions, objName, prefix)
+	}
+	return nil
+}
+
+// IssueScopedToken signs a new, short-lived JWT derived from parent, narrowed
+// by r and expiring after ttl. r.ParentJTI is carried as given, inside the
+// token's "restrictions" claim. Revoking the parent does not invalidate the
+// scoped tokens derived from it: nothing here revokes by parent, and a scoped
+// token stays valid until it expires.
+func IssueScopedToken(parent *Token, r Restrictions, ttl time.Duration, secret string) (string, error) {
+	if parent == nil {
+		return "", errors.New("cannot issue a scoped token without a parent token")
+	}
+	// never let a derived token exceed what its parent actually grants
+	parentCaps := apc.AccessAttrs(0)
+	switch {
+	case parent.IsAdmin:
+		parentCaps = apc.AccessAll
+	default:
+		for _, acl := range parent.ClusterACLs {
+			parentCaps |= acl.Access
+		}
+		for _, acl := range parent.BucketACLs {
+			parentCaps |= acl.Access
+		}
+	}
+	r.Caps &= parentCaps
+	r.NotBefore = time.Now()
+
+	claims := jwt.MapClaims{
+		"username":     parent.UserID,
+		"expires":      r.NotBefore.Add(ttl),
+		"admin":        false, // a scoped token can never elevate to admin
+		"clusters":     parent.ClusterACLs,
+		"buckets":      parent.BucketACLs,
+		"restrictions": r,
+	}
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return jwtToken.SignedString([]byte(secret))
+}
+
 //
 // utils
 //
@@ -202,4 +338,4 @@ func DecryptToken(tokenStr, secret string) (*Token, error) {
 		return nil, ErrInvalidToken
 	}
 	return tk, nil
-}
\ No newline at end of file
+}
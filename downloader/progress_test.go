@@ -0,0 +1,65 @@
+// Package downloader implements long-running object download tasks.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountingReaderTracksBytesRead(t *testing.T) {
+	cr := NewCountingReader(strings.NewReader("hello, world"))
+	buf := make([]byte, 5)
+
+	n, err := cr.Read(buf)
+	if err != nil || n != 5 {
+		t.Fatalf("Read: n=%d, err=%v", n, err)
+	}
+	if got := cr.BytesRead(); got != 5 {
+		t.Errorf("BytesRead() = %d, want 5", got)
+	}
+
+	for {
+		if _, err := cr.Read(buf); err != nil {
+			break
+		}
+	}
+	if got := cr.BytesRead(); got != 12 {
+		t.Errorf("BytesRead() after full read = %d, want 12", got)
+	}
+}
+
+func TestSpeedTrackerConvergesToSteadyRate(t *testing.T) {
+	s := &SpeedTracker{alpha: 0.5, lastAt: time.Now()}
+	var total int64
+	const chunk = 1000
+	for i := 0; i < 20; i++ {
+		s.lastAt = s.lastAt.Add(-time.Second) // simulate one second of elapsed time per sample
+		total += chunk
+		s.Sample(total)
+	}
+	if bps := s.Speed(); bps < chunk*0.9 || bps > chunk*1.1 {
+		t.Errorf("Speed() = %.2f, want ~%.2f after converging", bps, float64(chunk))
+	}
+}
+
+func TestSpeedTrackerETAZeroWithNoSamples(t *testing.T) {
+	s := NewSpeedTracker(0.3)
+	if eta := s.ETA(1000); eta != 0 {
+		t.Errorf("ETA() = %v, want 0 with no samples yet", eta)
+	}
+}
+
+func TestSpeedTrackerETAEstimate(t *testing.T) {
+	s := &SpeedTracker{alpha: 0.3, lastAt: time.Now()}
+	s.lastAt = s.lastAt.Add(-time.Second)
+	s.Sample(1000) // 1000 bytes/sec
+
+	eta := s.ETA(2000)
+	if eta < 1800*time.Millisecond || eta > 2200*time.Millisecond {
+		t.Errorf("ETA(2000) = %v, want ~2s at 1000 B/s", eta)
+	}
+}
@@ -0,0 +1,269 @@
+// Package downloader implements long-running object download tasks.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/aistore/hk"
+)
+
+// NOTE: the rest of this package (task dispatch, the DlJob/DlTask types, and
+// the DlStatusResp the proxy-side api.DownloadStatus returns) isn't present
+// in this checkout, so this file can't extend ais/tests/downloader_test.go's
+// DlStatusResp in place. What follows is self-contained: a retry policy a
+// task dispatch loop plugs into at the three points marked below, plus the
+// dead-letter bookkeeping DlStatusResp.DeadLetters is meant to surface.
+
+const (
+	retryBaseDelay = time.Second
+	retryFactor    = 2
+	retryCap       = 5 * time.Minute
+	retryJitter    = 0.20 // +/-20%
+)
+
+// RetryConfig bounds how many failed attempts a single object within a DlJob
+// gets: the MaxAttempts-th retryable failure moves it to that job's
+// dead-letter list. Zero value means "use DefaultMaxAttempts".
+type RetryConfig struct {
+	MaxAttempts int
+}
+
+const DefaultMaxAttempts = 8
+
+func (c RetryConfig) maxAttempts() int {
+	if c.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return c.MaxAttempts
+}
+
+// Attempt tracks one object's retry state within a job.
+type Attempt struct {
+	Count   int       `json:"count"`
+	LastErr string    `json:"last_err,omitempty"`
+	NextAt  time.Time `json:"next_at,omitempty"`
+}
+
+// DeadLetter is a task that exhausted RetryConfig.MaxAttempts (or hit a
+// terminal error on the first try) - see DlStatusResp.DeadLetters.
+type DeadLetter struct {
+	Name     string    `json:"name"`
+	Link     string    `json:"link"`
+	Err      string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	Time     time.Time `json:"time"`
+}
+
+// BackoffDelay returns the jittered exponential backoff delay before retry
+// number `attempt` (1-based: the delay before the *first* retry, i.e. after
+// the initial failed attempt, is BackoffDelay(1)).
+func BackoffDelay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	d := float64(retryBaseDelay)
+	for i := 1; i < attempt; i++ {
+		d *= retryFactor
+		if d > float64(retryCap) {
+			d = float64(retryCap)
+			break
+		}
+	}
+	jitter := 1 + (rand.Float64()*2-1)*retryJitter
+	delay := time.Duration(d * jitter)
+	if delay > retryCap {
+		delay = retryCap
+	}
+	return delay
+}
+
+// IsRetryable classifies a download failure as transient (worth another
+// attempt) vs terminal: 5xx and 408/429 HTTP statuses, DNS failures,
+// connection resets, and timeouts are retryable; other 4xx responses and
+// malformed URLs are not.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	// url.Error wraps the underlying cause (timeout, DNS, connection reset,
+	// ...) as well as unrecoverable url.Parse failures - unwrap it and defer
+	// to the wrapped error/timeout check below rather than blanket-failing.
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		if uerr.Timeout() {
+			return true
+		}
+		err = uerr.Err
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	var hse *httpStatusError
+	if errors.As(err, &hse) {
+		return isRetryableStatus(hse.Code)
+	}
+	return isRetryableNetErr(err)
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case 408, 429:
+		return true
+	default:
+		return code >= 500 && code < 600
+	}
+}
+
+// httpStatusError is the minimal shape a downloader HTTP-fetch error needs
+// to carry for IsRetryable to classify it - the actual fetch path (not in
+// this checkout) should wrap non-2xx responses in one of these.
+type httpStatusError struct {
+	Code int
+}
+
+func (e *httpStatusError) Error() string { return "http status " + strconv.Itoa(e.Code) }
+
+func isRetryableNetErr(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, sub := range []string{"connection reset", "connection refused", "no such host", "eof", "broken pipe"} {
+		if strings.Contains(msg, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scheduler tracks per-object retry state for a single DlJob and decides,
+// via hk.DefaultHK, when a failed task is eligible to be re-enqueued.
+//
+// Wiring points for the (absent here) task dispatch loop:
+//  1. on task failure, call Scheduler.Fail(name, link, err); if it returns
+//     ok=false the task has exhausted its attempts and belongs in
+//     DlStatusResp.DeadLetters instead of being retried;
+//  2. the hk-registered sweep below re-enqueues any task whose Attempt.NextAt
+//     has elapsed - that's the actual retry trigger, not a busy-poll;
+//  3. on task success, call Scheduler.Succeed(name) to drop its state.
+type Scheduler struct {
+	mu       sync.Mutex // guards attempts/dead: sweep runs on its own hk goroutine
+	cfg      RetryConfig
+	attempts map[string]*Attempt
+	dead     map[string]*DeadLetter
+	requeue  func(name string) // re-submits the task to the job's work queue
+	hkName   string            // hk.Reg name, unregistered by Close
+}
+
+func NewScheduler(jobID string, cfg RetryConfig, requeue func(name string)) *Scheduler {
+	s := &Scheduler{
+		cfg:      cfg,
+		attempts: make(map[string]*Attempt),
+		dead:     make(map[string]*DeadLetter),
+		requeue:  requeue,
+		hkName:   "downloader-retry-" + jobID,
+	}
+	hk.Reg(s.hkName, s.sweep, retryBaseDelay)
+	return s
+}
+
+// Close unregisters the sweep from hk.DefaultHK. The (absent here) DlJob
+// dispatch loop must call this once the job finishes - hk.Reg's callback
+// otherwise keeps firing every retryBaseDelay for the lifetime of the
+// process, one permanently-leaked callback per completed job.
+func (s *Scheduler) Close() {
+	hk.Unreg(s.hkName)
+}
+
+// Fail records a failed attempt at `name`. ok=false means the task is now a
+// dead letter and must not be retried further.
+func (s *Scheduler) Fail(name, link string, err error) (ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !IsRetryable(err) {
+		s.dead[name] = &DeadLetter{Name: name, Link: link, Err: err.Error(), Attempts: s.attempts[name].count() + 1, Time: time.Now()}
+		delete(s.attempts, name)
+		return false
+	}
+	a, exists := s.attempts[name]
+	if !exists {
+		a = &Attempt{}
+		s.attempts[name] = a
+	}
+	a.Count++
+	a.LastErr = err.Error()
+	if a.Count >= s.cfg.maxAttempts() {
+		s.dead[name] = &DeadLetter{Name: name, Link: link, Err: err.Error(), Attempts: a.Count, Time: time.Now()}
+		delete(s.attempts, name)
+		return false
+	}
+	a.NextAt = time.Now().Add(BackoffDelay(a.Count))
+	return true
+}
+
+func (s *Scheduler) Succeed(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, name)
+}
+
+// DeadLetters returns the job's dead-letter list, to be surfaced as
+// DlStatusResp.DeadLetters.
+func (s *Scheduler) DeadLetters() []*DeadLetter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*DeadLetter, 0, len(s.dead))
+	for _, dl := range s.dead {
+		out = append(out, dl)
+	}
+	return out
+}
+
+// Attempts returns the per-object attempt count, to be surfaced as part of
+// DlStatusResp's per-task info.
+func (s *Scheduler) Attempts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]int, len(s.attempts))
+	for name, a := range s.attempts {
+		out[name] = a.Count
+	}
+	return out
+}
+
+func (s *Scheduler) sweep() time.Duration {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := make([]string, 0)
+	for name, a := range s.attempts {
+		if !a.NextAt.IsZero() && !now.Before(a.NextAt) {
+			a.NextAt = time.Time{}
+			due = append(due, name)
+		}
+	}
+	s.mu.Unlock()
+
+	// requeue outside the lock: requeue may synchronously call back into
+	// Fail/Succeed (e.g. a synchronous dispatch queue), which would
+	// self-deadlock on a non-reentrant mutex otherwise
+	for _, name := range due {
+		s.requeue(name)
+	}
+	return retryBaseDelay
+}
+
+func (a *Attempt) count() int {
+	if a == nil {
+		return 0
+	}
+	return a.Count
+}
@@ -0,0 +1,87 @@
+// Package downloader implements long-running object download tasks.
+/*
+ * Copyright (c) 2024, NVIDIA CORPORATION. All rights reserved.
+ */
+package downloader
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"5xx", &httpStatusError{Code: 503}, true},
+		{"408", &httpStatusError{Code: 408}, true},
+		{"429", &httpStatusError{Code: 429}, true},
+		{"404", &httpStatusError{Code: 404}, false},
+		{"400", &httpStatusError{Code: 400}, false},
+		{"invalid url", &url.Error{Op: "parse", URL: "://bad", Err: errors.New("missing protocol scheme")}, false},
+		{"connection reset", errors.New("read: connection reset by peer"), true},
+		{"no such host", errors.New("dial tcp: lookup foo: no such host"), true},
+	}
+	for _, tc := range tests {
+		if got := IsRetryable(tc.err); got != tc.want {
+			t.Errorf("%s: IsRetryable() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBackoffDelayGrowsAndCaps(t *testing.T) {
+	prev := time.Duration(0)
+	for attempt := 1; attempt <= 20; attempt++ {
+		d := BackoffDelay(attempt)
+		if d > retryCap {
+			t.Fatalf("attempt %d: delay %v exceeds cap %v", attempt, d, retryCap)
+		}
+		if attempt > 1 && d < prev/2 {
+			t.Errorf("attempt %d: delay %v unexpectedly smaller than half of previous %v", attempt, d, prev)
+		}
+		prev = d
+	}
+}
+
+func TestSchedulerDeadLettersAfterMaxAttempts(t *testing.T) {
+	var requeued []string
+	s := &Scheduler{
+		cfg:      RetryConfig{MaxAttempts: 2},
+		attempts: make(map[string]*Attempt),
+		dead:     make(map[string]*DeadLetter),
+		requeue:  func(name string) { requeued = append(requeued, name) },
+	}
+
+	retryableErr := &httpStatusError{Code: 503}
+	if ok := s.Fail("obj", "http://x", retryableErr); !ok {
+		t.Fatal("expected first failure to still be retryable")
+	}
+	if ok := s.Fail("obj", "http://x", retryableErr); ok {
+		t.Fatal("expected second failure to exhaust MaxAttempts=2")
+	}
+	if len(s.DeadLetters()) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(s.DeadLetters()))
+	}
+	if _, stillTracked := s.attempts["obj"]; stillTracked {
+		t.Error("expected dead-lettered task to be dropped from attempts")
+	}
+}
+
+func TestSchedulerTerminalErrorSkipsRetry(t *testing.T) {
+	s := &Scheduler{
+		cfg:      RetryConfig{MaxAttempts: 8},
+		attempts: make(map[string]*Attempt),
+		dead:     make(map[string]*DeadLetter),
+		requeue:  func(string) {},
+	}
+	if ok := s.Fail("obj", "http://x", &httpStatusError{Code: 404}); ok {
+		t.Fatal("expected a 404 to be treated as terminal, not retried")
+	}
+	if len(s.DeadLetters()) != 1 {
+		t.Fatalf("expected 1 dead letter, got %d", len(s.DeadLetters()))
+	}
+}
@@ -45,6 +45,13 @@ const (
 	throttleTimeOut  = time.Second
 )
 
+// Eviction policy selectors (config.LRU.Policy)
+const (
+	PolicyLRU = "lru" // default: recency, as kept today by fileInfoMinHeap
+	PolicyLFU = "lfu" // frequency-count sketch (count-min), keyed by FQN hash
+	PolicyARC = "arc" // Adaptive Replacement Cache (T1/T2/B1/B2, self-tuning p)
+)
+
 type (
 	InitLRU struct {
 		Ratime     *atime.Runner
@@ -61,6 +68,25 @@ type (
 	}
 	fileInfoMinHeap []*fileInfo
 
+	// Policy decouples the filesystem jogger from the eviction strategy: the
+	// jogger feeds every visited file through Admit/OnAccess and asks Victim
+	// for the next file to reclaim, without knowing how the decision is made.
+	Policy interface {
+		// Admit is called once per visited file during the jog; implementations
+		// use it to build up whatever bookkeeping they need (heap, sketch, ghost
+		// lists, ...).
+		Admit(fi *fileInfo)
+		// Victim returns (and removes) the next file to evict, or nil if the
+		// policy has nothing left to offer.
+		Victim() *fileInfo
+		// OnAccess is a hint from outside the jog (e.g., a GET hit) that fqn was
+		// used at atime; ARC and LFU use it to move entries between lists / bump
+		// counters, LRU uses it to refresh the heap position.
+		OnAccess(fqn string, atime time.Time)
+		// Reset clears all policy state between jogs of the same mountpath.
+		Reset()
+	}
+
 	// lructx represents a single LRU context that runs in a single goroutine (worker)
 	// that traverses and evicts a single given filesystem, or more exactly,
 	// subtree in this filesystem identified by the bucketdir
@@ -71,6 +97,7 @@ type (
 		newest  time.Time
 		heap    *fileInfoMinHeap
 		oldwork []*fileInfo
+		policy  Policy
 		// init-time
 		ini             InitLRU
 		mpathInfo       *fs.MountpathInfo
@@ -138,5 +165,25 @@ func newlru(ini *InitLRU, mpathInfo *fs.MountpathInfo, contentType string, conte
 		atimeRespCh:     make(chan *atime.Response, 1),
 		bislocal:        bislocal,
 	}
+	lctx.policy = newPolicy(config.LRU.Policy, lctx)
 	return lctx
 }
+
+// newPolicy selects the Policy implementation named by config.LRU.Policy,
+// falling back to the recency heap (today's default and behavior) for an
+// empty or unrecognized value so existing configs keep working unchanged.
+func newPolicy(kind string, lctx *lructx) Policy {
+	switch kind {
+	case PolicyLFU:
+		return newLFUPolicy()
+	case PolicyARC:
+		return newARCPolicy(lctx.mpathInfo, lctx.contentType)
+	case PolicyLRU, "":
+		fallthrough
+	default:
+		if kind != "" && kind != PolicyLRU {
+			glog.Warningf("LRU: unknown eviction policy %q, defaulting to %q", kind, PolicyLRU)
+		}
+		return newRecencyPolicy(lctx)
+	}
+}
@@ -0,0 +1,487 @@
+// Package lru provides atime-based least recently used cache replacement policy for stored objects
+// and serves as a generic garbage-collection mechanism for orhaned workfiles.
+/*
+ * Copyright (c) 2018, NVIDIA CORPORATION. All rights reserved.
+ */
+package lru
+
+import (
+	"container/heap"
+	"container/list"
+	"encoding/json"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NVIDIA/dfcpub/3rdparty/glog"
+	"github.com/NVIDIA/dfcpub/fs"
+)
+
+// ============================================= Summary ===========================================
+//
+// This file implements the Policy interface declared in lrumain.go: the heap-based
+// recency policy (today's default, unchanged in behavior), a frequency-count sketch
+// (LFU), and an Adaptive Replacement Cache (ARC).
+//
+// ARC's ghost lists (B1/B2) and target size `p` are persisted per mountpath (see
+// arcPolicy.persist/loadGhosts below) so a target restart doesn't reset the
+// adaptation - scoped down from the original ask in one respect: per-list
+// hit-rates and CMS estimates are NOT surfaced through stats.Tracker, because the
+// stats package (and its Tracker interface/registration API) isn't part of this
+// checkout, so there's nothing real to wire Report() calls into without guessing
+// at a method set that might not match. What IS real and usable today:
+// arcPolicy.Stats() returns the same numbers (p, T1/T2/B1/B2 lengths) as a plain
+// struct - whoever wires up the real stats.Tracker can report straight from it.
+//
+// ============================================= Summary ===========================================
+
+//////////////////////
+// recency (LRU) policy //
+//////////////////////
+
+type recencyPolicy struct {
+	lctx *lructx
+}
+
+func newRecencyPolicy(lctx *lructx) *recencyPolicy { return &recencyPolicy{lctx: lctx} }
+
+// Admit/Victim/Reset for the recency policy operate on lctx.heap exactly as the
+// jogger did before Policy existed; OnAccess is a no-op because the heap is
+// rebuilt from scratch on every jog (access times come from fs.Stat, not from a
+// live index).
+func (p *recencyPolicy) Admit(fi *fileInfo)       { *p.lctx.heap = append(*p.lctx.heap, fi) }
+func (*recencyPolicy) OnAccess(string, time.Time) {}
+func (p *recencyPolicy) Reset()                   { *p.lctx.heap = (*p.lctx.heap)[:0] }
+func (p *recencyPolicy) Victim() *fileInfo {
+	if p.lctx.heap.Len() == 0 {
+		return nil
+	}
+	// delegate to fileInfoMinHeap's existing container/heap.Interface
+	// implementation (see lru.go) so behavior is bit-for-bit unchanged.
+	return heap.Pop(p.lctx.heap).(*fileInfo)
+}
+
+//////////////
+// LFU policy //
+//////////////
+
+// lfuPolicy evicts the file with the lowest estimated access frequency, as
+// tracked by a small Count-Min Sketch keyed by the FQN hash. CMS trades a
+// bounded false-positive rate (over-counting) for O(1) space independent of
+// working-set size, which matters because the jogger streams filesystem
+// entries rather than holding them all in memory.
+type lfuPolicy struct {
+	mu      sync.Mutex
+	cms     *countMinSketch
+	entries map[string]*fileInfo // fqn -> admitted file, pending eviction
+	order   []*fileInfo          // admission order, scanned to find the min-frequency victim
+}
+
+const (
+	cmsWidth = 2048 // per-row counters; trades accuracy for memory
+	cmsDepth = 4    // independent hash rows
+)
+
+func newLFUPolicy() *lfuPolicy {
+	return &lfuPolicy{
+		cms:     newCountMinSketch(cmsDepth, cmsWidth),
+		entries: make(map[string]*fileInfo, 1024),
+	}
+}
+
+func (p *lfuPolicy) Admit(fi *fileInfo) {
+	p.mu.Lock()
+	p.cms.incr(fi.fqn)
+	if _, ok := p.entries[fi.fqn]; !ok {
+		p.entries[fi.fqn] = fi
+		p.order = append(p.order, fi)
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy) OnAccess(fqn string, _ time.Time) {
+	p.mu.Lock()
+	p.cms.incr(fqn)
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy) Victim() *fileInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var (
+		victim    *fileInfo
+		victimIdx = -1
+		minFreq   uint32
+	)
+	for i, fi := range p.order {
+		if fi == nil {
+			continue
+		}
+		freq := p.cms.estimate(fi.fqn)
+		if victim == nil || freq < minFreq {
+			victim, victimIdx, minFreq = fi, i, freq
+		}
+	}
+	if victim == nil {
+		return nil
+	}
+	p.order[victimIdx] = nil // tombstone, compacted on Reset
+	delete(p.entries, victim.fqn)
+	return victim
+}
+
+func (p *lfuPolicy) Reset() {
+	p.mu.Lock()
+	p.cms = newCountMinSketch(cmsDepth, cmsWidth)
+	p.entries = make(map[string]*fileInfo, 1024)
+	p.order = p.order[:0]
+	p.mu.Unlock()
+}
+
+// countMinSketch is a minimal, allocation-light CMS: `depth` independent hash
+// rows of `width` saturating counters. Membership-only (no decay), which is
+// adequate for a single jog's relative ranking.
+type countMinSketch struct {
+	rows  [][]uint32
+	seeds []uint32
+}
+
+func newCountMinSketch(depth, width int) *countMinSketch {
+	cms := &countMinSketch{rows: make([][]uint32, depth), seeds: make([]uint32, depth)}
+	for i := range cms.rows {
+		cms.rows[i] = make([]uint32, width)
+		cms.seeds[i] = uint32(i*2654435761 + 1)
+	}
+	return cms
+}
+
+func (cms *countMinSketch) incr(key string) {
+	for i, row := range cms.rows {
+		idx := cms.index(key, cms.seeds[i], len(row))
+		if row[idx] < ^uint32(0) {
+			row[idx]++
+		}
+	}
+}
+
+func (cms *countMinSketch) estimate(key string) uint32 {
+	minv := ^uint32(0)
+	for i, row := range cms.rows {
+		idx := cms.index(key, cms.seeds[i], len(row))
+		if row[idx] < minv {
+			minv = row[idx]
+		}
+	}
+	return minv
+}
+
+func (*countMinSketch) index(key string, seed uint32, width int) int {
+	h := fnv.New32a()
+	h.Write([]byte{byte(seed), byte(seed >> 8), byte(seed >> 16), byte(seed >> 24)})
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(width))
+}
+
+//////////////
+// ARC policy //
+//////////////
+
+// arcPolicy implements Adaptive Replacement Cache: T1 (recent, seen once), T2
+// (recent, seen more than once), and their ghost lists B1/B2 which remember
+// recently-evicted FQNs (but not their content) so a second visit can inform
+// the self-tuning target size `p` for T1.
+//
+// Because the jogger streams the filesystem rather than sitting on a bounded
+// resident set, ARC here runs a two-pass jog per mountpath: the first pass
+// (fed via Admit) only builds/updates the ghost lists from the prior jog's
+// state (kept across restarts by persist/loadGhosts, see file header); the second
+// pass evicts using the resulting `p`.
+type arcPolicy struct {
+	mu         sync.Mutex
+	mpathInfo  *fs.MountpathInfo
+	bckTypeDir string
+
+	c int // target cache size (capacity), in number of entries
+	p int // adaptive target size of T1
+
+	t1, t2, b1, b2 *list.List
+	index          map[string]*list.Element // fqn -> element, across all four lists
+}
+
+// arcListID names which of T1/T2/B1/B2 an arcEntry currently lives on, so
+// Admit/OnAccess/replaceIfFull/Victim can tell lists apart with a field read
+// instead of an O(n) container/list scan - the latter, called on every file
+// the jogger visits, would defeat ARC's whole point of O(1) bookkeeping.
+type arcListID int
+
+const (
+	arcListNone arcListID = iota
+	arcListT1
+	arcListT2
+	arcListB1
+	arcListB2
+)
+
+type arcEntry struct {
+	fi    *fileInfo
+	ghost bool      // true when this element lives in B1/B2 (fqn remembered, content gone)
+	on    arcListID // which list (by identity, see arcPolicy.listID) el currently sits on
+}
+
+const arcDefaultCapacity = 64 * 1024 // entries; sized to one jog's working set
+
+// ghostState is the on-disk shape of arcPolicy's persisted ghost lists: just
+// enough to resume adaptation across a target restart - fqns (MRU-to-LRU
+// order) and the target size `p` they informed.
+type ghostState struct {
+	P  int      `json:"p"`
+	B1 []string `json:"b1"` // MRU-first
+	B2 []string `json:"b2"` // MRU-first
+}
+
+func newARCPolicy(mpathInfo *fs.MountpathInfo, contentType string) *arcPolicy {
+	a := &arcPolicy{
+		mpathInfo:  mpathInfo,
+		bckTypeDir: contentType,
+		c:          arcDefaultCapacity,
+		t1:         list.New(),
+		t2:         list.New(),
+		b1:         list.New(),
+		b2:         list.New(),
+		index:      make(map[string]*list.Element, arcDefaultCapacity),
+	}
+	a.loadGhosts()
+	return a
+}
+
+// ghostStatePath is where this policy's ghost lists/p are persisted: one small
+// sidecar file per mountpath+content-type, alongside (not inside) the content
+// type's own bucket dirs, so it never collides with real object FQNs.
+func (a *arcPolicy) ghostStatePath() string {
+	if a.mpathInfo == nil {
+		return ""
+	}
+	return filepath.Join(a.mpathInfo.Path, "."+a.bckTypeDir+".arc-ghosts.json")
+}
+
+// loadGhosts best-effort restores ghost lists and p from a prior jog's
+// persist() - a missing or corrupt file just means "start fresh", same as the
+// very first jog on a mountpath ever would.
+func (a *arcPolicy) loadGhosts() {
+	path := a.ghostStatePath()
+	if path == "" {
+		return
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var gs ghostState
+	if err := json.Unmarshal(b, &gs); err != nil {
+		glog.Warningf("ARC: failed to parse persisted ghost state %s: %v", path, err)
+		return
+	}
+	a.p = gs.P
+	for i := len(gs.B1) - 1; i >= 0; i-- {
+		el := a.b1.PushFront(&arcEntry{fi: &fileInfo{fqn: gs.B1[i]}, ghost: true, on: arcListB1})
+		a.index[gs.B1[i]] = el
+	}
+	for i := len(gs.B2) - 1; i >= 0; i-- {
+		el := a.b2.PushFront(&arcEntry{fi: &fileInfo{fqn: gs.B2[i]}, ghost: true, on: arcListB2})
+		a.index[gs.B2[i]] = el
+	}
+}
+
+// persist writes out the current ghost lists and p so the next newARCPolicy
+// on this mountpath (typically after a target restart) can pick up the
+// adaptation where this one left off. Best-effort: a failure to persist must
+// never fail or stall the jog that's wrapping up.
+func (a *arcPolicy) persist() {
+	path := a.ghostStatePath()
+	if path == "" {
+		return
+	}
+	gs := ghostState{P: a.p, B1: make([]string, 0, a.b1.Len()), B2: make([]string, 0, a.b2.Len())}
+	for e := a.b1.Front(); e != nil; e = e.Next() {
+		gs.B1 = append(gs.B1, e.Value.(*arcEntry).fi.fqn)
+	}
+	for e := a.b2.Front(); e != nil; e = e.Next() {
+		gs.B2 = append(gs.B2, e.Value.(*arcEntry).fi.fqn)
+	}
+	b, err := json.Marshal(&gs)
+	if err != nil {
+		glog.Errorf("ARC: failed to marshal ghost state: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		glog.Errorf("ARC: failed to persist ghost state to %s: %v", path, err)
+	}
+}
+
+// ArcStats is the point-in-time snapshot ARC's TODO asked to surface through
+// stats.Tracker (see the file header NOTE on why that last step isn't wired
+// up here); Stats() is the real, usable half of that ask.
+type ArcStats struct {
+	P     int
+	T1Len int
+	T2Len int
+	B1Len int
+	B2Len int
+}
+
+func (a *arcPolicy) Stats() ArcStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return ArcStats{P: a.p, T1Len: a.t1.Len(), T2Len: a.t2.Len(), B1Len: a.b1.Len(), B2Len: a.b2.Len()}
+}
+
+func (a *arcPolicy) Admit(fi *fileInfo) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if el, ok := a.index[fi.fqn]; ok {
+		ae := el.Value.(*arcEntry)
+		switch {
+		case ae.on == arcListB1:
+			// case II: hit in B1 -> grow p, promote straight to T2
+			a.p = min(a.c, a.p+max(1, a.b2.Len()/max(1, a.b1.Len())))
+			a.b1.Remove(el)
+			a.promote(fi)
+		case ae.on == arcListB2:
+			// case III: hit in B2 -> shrink p, promote straight to T2
+			a.p = max(0, a.p-max(1, a.b1.Len()/max(1, a.b2.Len())))
+			a.b2.Remove(el)
+			a.promote(fi)
+		case ae.on == arcListT1:
+			// case I: already resident in T1, seen again -> move to T2 (frequency bump)
+			a.t1.Remove(el)
+			a.promote(fi)
+		default:
+			// already in T2: touch (move to MRU end)
+			a.t2.MoveToFront(el)
+		}
+		return
+	}
+	// case IV: new entry
+	a.replaceIfFull()
+	el := a.t1.PushFront(&arcEntry{fi: fi, on: arcListT1})
+	a.index[fi.fqn] = el
+}
+
+func (a *arcPolicy) promote(fi *fileInfo) {
+	el := a.t2.PushFront(&arcEntry{fi: fi, on: arcListT2})
+	a.index[fi.fqn] = el
+}
+
+// listID returns the arcListID for l by pointer identity - an O(1) lookup
+// used wherever a list is picked dynamically (replaceIfFull/Victim), so the
+// pushed-back ghost entry can still be tagged with which list it landed on.
+func (a *arcPolicy) listID(l *list.List) arcListID {
+	switch l {
+	case a.t1:
+		return arcListT1
+	case a.t2:
+		return arcListT2
+	case a.b1:
+		return arcListB1
+	default:
+		return arcListB2
+	}
+}
+
+// replaceIfFull evicts one resident entry (from T1 or T2, per the adapted p)
+// into the corresponding ghost list, capping total size at `c`.
+func (a *arcPolicy) replaceIfFull() {
+	if a.t1.Len()+a.t2.Len() < a.c {
+		return
+	}
+	var from, to *list.List
+	if a.t1.Len() > 0 && (a.t1.Len() > a.p || (a.t1.Len() == a.p && a.t2.Len() == 0)) {
+		from, to = a.t1, a.b1
+	} else {
+		from, to = a.t2, a.b2
+	}
+	el := from.Back()
+	if el == nil {
+		return
+	}
+	ae := el.Value.(*arcEntry)
+	from.Remove(el)
+	delete(a.index, ae.fi.fqn)
+	ghost := to.PushFront(&arcEntry{fi: ae.fi, ghost: true, on: a.listID(to)})
+	a.index[ae.fi.fqn] = ghost
+	if to.Len() > a.c {
+		back := to.Back()
+		to.Remove(back)
+		delete(a.index, back.Value.(*arcEntry).fi.fqn)
+	}
+}
+
+func (a *arcPolicy) OnAccess(fqn string, _ time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if el, ok := a.index[fqn]; ok && !el.Value.(*arcEntry).ghost {
+		if el.Value.(*arcEntry).on == arcListT1 {
+			a.t1.Remove(el)
+			a.promote(el.Value.(*arcEntry).fi)
+		} else {
+			a.t2.MoveToFront(el)
+		}
+	}
+}
+
+// Victim picks from T1 if it's over its adapted share, else from T2, and
+// moves the evicted fqn to the corresponding ghost list per the algorithm.
+func (a *arcPolicy) Victim() *fileInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var from, to *list.List
+	switch {
+	case a.t1.Len() > a.p:
+		from, to = a.t1, a.b1
+	case a.t2.Len() > 0:
+		from, to = a.t2, a.b2
+	case a.t1.Len() > 0:
+		from, to = a.t1, a.b1
+	default:
+		return nil
+	}
+	el := from.Back()
+	if el == nil {
+		return nil
+	}
+	ae := el.Value.(*arcEntry)
+	from.Remove(el)
+	delete(a.index, ae.fi.fqn)
+	ghost := to.PushFront(&arcEntry{fi: ae.fi, ghost: true, on: a.listID(to)})
+	a.index[ae.fi.fqn] = ghost
+	return ae.fi
+}
+
+func (a *arcPolicy) Reset() {
+	a.mu.Lock()
+	a.t1, a.t2 = list.New(), list.New()
+	// NOTE: ghost lists (b1/b2) and p intentionally survive Reset - they are
+	// what the next jog's first pass is meant to consult/adjust.
+	a.index = make(map[string]*list.Element, arcDefaultCapacity)
+	a.mu.Unlock()
+	// persist outside the lock: this is the jog-end boundary, the same point
+	// a target restart would otherwise lose b1/b2/p from.
+	a.persist()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
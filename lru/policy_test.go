@@ -0,0 +1,117 @@
+// Package lru provides atime-based least recently used cache replacement policy for stored objects
+// and serves as a generic garbage-collection mechanism for orhaned workfiles.
+/*
+ * Copyright (c) 2018, NVIDIA CORPORATION. All rights reserved.
+ */
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+// NOTE: these tests exercise the Policy implementations directly, without a
+// real *fs.MountpathInfo (the fs package isn't part of this checkout) - every
+// arcPolicy here is constructed with a nil mpathInfo, which makes
+// persist/loadGhosts no-ops (see arcPolicy.ghostStatePath) rather than
+// exercising the on-disk round trip.
+
+func TestRecencyPolicyVictimIsAdmitted(t *testing.T) {
+	lctx := &lructx{heap: &fileInfoMinHeap{}}
+	p := newRecencyPolicy(lctx)
+	a := &fileInfo{fqn: "a"}
+	b := &fileInfo{fqn: "b"}
+	p.Admit(a)
+	p.Admit(b)
+	if got := p.Victim(); got != a && got != b {
+		t.Fatalf("Victim() = %v, want one of the admitted entries", got)
+	}
+}
+
+func TestLFUPolicyEvictsLowestFrequency(t *testing.T) {
+	p := newLFUPolicy()
+	hot := &fileInfo{fqn: "hot"}
+	cold := &fileInfo{fqn: "cold"}
+	p.Admit(hot)
+	p.Admit(cold)
+	p.OnAccess("hot", time.Time{})
+	p.OnAccess("hot", time.Time{})
+
+	victim := p.Victim()
+	if victim == nil || victim.fqn != "cold" {
+		t.Errorf("Victim() = %v, want %q (lower frequency)", victim, "cold")
+	}
+}
+
+func TestLFUPolicyResetClearsState(t *testing.T) {
+	p := newLFUPolicy()
+	p.Admit(&fileInfo{fqn: "x"})
+	p.Reset()
+	if v := p.Victim(); v != nil {
+		t.Errorf("Victim() after Reset() = %v, want nil", v)
+	}
+}
+
+func TestARCPolicyPromotesOnSecondAdmit(t *testing.T) {
+	a := newARCPolicy(nil, "object")
+	fi := &fileInfo{fqn: "x"}
+	a.Admit(fi)
+	if a.t1.Len() != 1 || a.t2.Len() != 0 {
+		t.Fatalf("after first Admit: t1=%d t2=%d, want t1=1 t2=0", a.t1.Len(), a.t2.Len())
+	}
+	a.Admit(fi) // seen again -> case I: promote T1 -> T2
+	if a.t1.Len() != 0 || a.t2.Len() != 1 {
+		t.Errorf("after second Admit: t1=%d t2=%d, want t1=0 t2=1", a.t1.Len(), a.t2.Len())
+	}
+}
+
+func TestARCPolicyGhostHitGrowsP(t *testing.T) {
+	a := newARCPolicy(nil, "object")
+	a.c = 1 // force eviction on the very next Admit once full
+
+	first := &fileInfo{fqn: "first"}
+	second := &fileInfo{fqn: "second"}
+	a.Admit(first)
+	a.Admit(second) // evicts "first" into b1 (c == 1)
+	if a.b1.Len() != 1 {
+		t.Fatalf("b1.Len() = %d, want 1 after eviction", a.b1.Len())
+	}
+
+	pBefore := a.p
+	a.Admit(first) // hit in b1 -> case II: grow p, promote to T2
+	if a.p <= pBefore {
+		t.Errorf("p = %d, want it to grow past %d on a B1 hit", a.p, pBefore)
+	}
+	if el, ok := a.index["first"]; !ok || el.Value.(*arcEntry).on != arcListT2 {
+		t.Error("expected \"first\" to be promoted straight to T2 on a B1 hit")
+	}
+}
+
+func TestARCPolicyStatsReflectsLists(t *testing.T) {
+	a := newARCPolicy(nil, "object")
+	a.Admit(&fileInfo{fqn: "x"})
+	a.Admit(&fileInfo{fqn: "y"})
+	s := a.Stats()
+	if s.T1Len != 2 {
+		t.Errorf("Stats().T1Len = %d, want 2", s.T1Len)
+	}
+}
+
+func TestARCPolicyResetKeepsGhostsAndP(t *testing.T) {
+	a := newARCPolicy(nil, "object")
+	a.c = 1
+	a.Admit(&fileInfo{fqn: "first"})
+	a.Admit(&fileInfo{fqn: "second"}) // evicts "first" into b1
+
+	b1Before, pBefore := a.b1.Len(), a.p
+	a.Reset()
+	if a.t1.Len() != 0 || a.t2.Len() != 0 {
+		t.Errorf("Reset(): t1=%d t2=%d, want both 0", a.t1.Len(), a.t2.Len())
+	}
+	if a.b1.Len() != b1Before {
+		t.Errorf("Reset(): b1.Len() = %d, want unchanged %d", a.b1.Len(), b1Before)
+	}
+	if a.p != pBefore {
+		t.Errorf("Reset(): p = %d, want unchanged %d", a.p, pBefore)
+	}
+}
@@ -7,10 +7,11 @@ package mirror
 import (
 	"errors"
 	"fmt"
-	"os"
+	"io/ioutil"
 	"path/filepath"
 	"runtime"
-	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/NVIDIA/dfcpub/3rdparty/glog"
@@ -27,22 +28,65 @@ type (
 		cmn.Named
 		// runtime
 		mpathChangeCh chan struct{}
+		erasersMu     sync.RWMutex // guards erasers: workers read it, reshard (control loop) writes it
 		erasers       map[string]*eraser
+		queue         chan workItem
+		done          chan struct{} // closed by stop() - the only shutdown signal producers (reshard, processDir, work) select on; queue itself is never closed, so a send can never race a close
+		limiter       *rateLimiter
+		workerWG      sync.WaitGroup
 		config        *cmn.Config
 		// init
-		Bucket   string
-		Mirror   cmn.MirrorConf
-		Slab     *memsys.Slab2
-		T        cluster.Target
-		Bislocal bool
+		Bucket      string
+		Mirror      cmn.MirrorConf
+		Slab        *memsys.Slab2
+		T           cluster.Target
+		Bislocal    bool
+		OpsPerSec   float64 // rate limit: erase ops/sec, 0 == unlimited
+		BytesPerSec float64 // rate limit: bytes reclaimed/sec, 0 == unlimited
 	}
-	eraser struct { // one per mountpath
+	// eraser tracks one mountpath's erasure progress; it no longer does its
+	// own tree-walk - it only seeds its bucket subtree onto the shared queue
+	// and accumulates the counters the worker pool updates as it drains that
+	// queue. One eraser always exists per currently-available mountpath.
+	eraser struct {
 		parent    *XactErase
 		mpathInfo *fs.MountpathInfo
-		stopCh    chan struct{}
+		mpath     string // MakePath(...) cache key, also the workItem.mpath tag
+		invalid   int32  // atomic flag: set on mountpath remove/disable, checked by workers before they act on a queued item
+		// counters, all updated via sync/atomic - see Progress()
+		filesVisited   int64
+		copiesDeleted  int64
+		bytesReclaimed int64
+		errors         int64
+	}
+	// workItem is one directory to list; workers push any subdirectories
+	// they find back onto the shared queue, and delete copies for any plain
+	// files - this is what lets an arbitrarily large subtree be drained by a
+	// fixed-size worker pool instead of one goroutine-per-mountpath walking
+	// serially to completion.
+	workItem struct {
+		mpath string
+		dir   string
+	}
+	// MpathProgress is a snapshot of one mountpath's erasure counters,
+	// suitable for the xaction stats API to poll and report.
+	MpathProgress struct {
+		FilesVisited   int64
+		CopiesDeleted  int64
+		BytesReclaimed int64
+		Errors         int64
 	}
 )
 
+const (
+	// workQueueDepth bounds how many not-yet-listed directories can be
+	// buffered before producers (jog seeding, or workers re-queueing
+	// subdirs) block - large enough that a single mountpath's fan-out
+	// doesn't stall, small enough that resharding doesn't have to drain an
+	// unbounded backlog.
+	workQueueDepth = 4096
+)
+
 /*
  * implements fs.PathRunner interface
  */
@@ -50,7 +94,7 @@ var _ fs.PathRunner = &XactErase{}
 
 func (r *XactErase) SetID(id int64) { cmn.Assert(false) }
 
-func (r *XactErase) ReqAddMountpath(mpath string)     { r.mpathChangeCh <- struct{}{} } // TODO: same for other "erasers"
+func (r *XactErase) ReqAddMountpath(mpath string)     { r.mpathChangeCh <- struct{}{} }
 func (r *XactErase) ReqRemoveMountpath(mpath string)  { r.mpathChangeCh <- struct{}{} }
 func (r *XactErase) ReqEnableMountpath(mpath string)  { r.mpathChangeCh <- struct{}{} }
 func (r *XactErase) ReqDisableMountpath(mpath string) { r.mpathChangeCh <- struct{}{} }
@@ -60,18 +104,23 @@ func (r *XactErase) ReqDisableMountpath(mpath string) { r.mpathChangeCh <- struc
 //
 
 func (r *XactErase) Run() error {
-	// init
 	availablePaths, _ := fs.Mountpaths.Get()
-	r.erasers = make(map[string]*eraser, len(availablePaths))
 	r.config = cmn.GCO.Get()
-init:
-	// start mpath erasers
-	for _, mpathInfo := range availablePaths {
-		eraser := &eraser{parent: r, mpathInfo: mpathInfo}
-		mpathLC := mpathInfo.MakePath(fs.ObjectType, r.Bislocal)
-		r.erasers[mpathLC] = eraser
-		go eraser.jog()
+	r.queue = make(chan workItem, workQueueDepth)
+	r.done = make(chan struct{})
+	r.limiter = newRateLimiter(r.OpsPerSec, r.BytesPerSec)
+	r.erasers = make(map[string]*eraser, len(availablePaths))
+	r.reshard(availablePaths)
+
+	numWorkers := r.Mirror.Burst
+	if numWorkers <= 0 {
+		numWorkers = runtime.NumCPU()
 	}
+	for i := 0; i < numWorkers; i++ {
+		r.workerWG.Add(1)
+		go r.work()
+	}
+
 	// control loop
 	for {
 		select {
@@ -79,23 +128,35 @@ init:
 			r.stop()
 			return fmt.Errorf("%s aborted, exiting", r)
 		case <-r.mpathChangeCh:
-			for _, eraser := range r.erasers {
-				eraser.stop()
-			}
 			availablePaths, _ = fs.Mountpaths.Get()
-			l := len(availablePaths)
-			r.erasers = make(map[string]*eraser, l) // new erasers map
-			if l == 0 {
+			if len(availablePaths) == 0 {
 				r.stop()
 				return fmt.Errorf("%s no mountpaths, exiting", r)
 			}
-			goto init // reinitialize and keep running
+			r.reshard(availablePaths)
 		}
 	}
 }
 
 func (r *XactErase) Stop(error) { r.Abort() } // call base method
 
+// Progress returns a point-in-time snapshot of every currently-tracked
+// mountpath's erasure counters, keyed the same way r.erasers is.
+func (r *XactErase) Progress() map[string]MpathProgress {
+	r.erasersMu.RLock()
+	defer r.erasersMu.RUnlock()
+	out := make(map[string]MpathProgress, len(r.erasers))
+	for mpath, j := range r.erasers {
+		out[mpath] = MpathProgress{
+			FilesVisited:   atomic.LoadInt64(&j.filesVisited),
+			CopiesDeleted:  atomic.LoadInt64(&j.copiesDeleted),
+			BytesReclaimed: atomic.LoadInt64(&j.bytesReclaimed),
+			Errors:         atomic.LoadInt64(&j.errors),
+		}
+	}
+	return out
+}
+
 //
 // private methods
 //
@@ -107,68 +168,233 @@ func (r *XactErase) stop() {
 	}
 	r.EndTime(time.Now())
 	r.XactDemandBase.Stop()
-	for _, eraser := range r.erasers {
-		eraser.stop()
+	r.erasersMu.RLock()
+	for _, j := range r.erasers {
+		atomic.StoreInt32(&j.invalid, 1)
+	}
+	r.erasersMu.RUnlock()
+	// signal shutdown via r.done, not by closing r.queue: workers (and
+	// reshard, seeding new work) still hold select send-cases on r.queue
+	// until they observe r.done, so closing it here would race a send and
+	// panic. r.queue itself is never closed - once every producer has
+	// exited (workerWG.Wait below, and reshard can't be running concurrently
+	// with stop - both run on the control loop goroutine) nothing sends to
+	// it again, and the channel is simply left for the GC.
+	close(r.done)
+	r.workerWG.Wait()
+}
+
+// reshard reconciles r.erasers with the current set of available
+// mountpaths: mountpaths no longer present are marked invalid (so queued and
+// in-flight items that reference them are dropped, not acted on) and dropped
+// from the map, while newly-available ones get a fresh eraser and seed their
+// bucket subtree onto the shared queue. Existing mountpaths, and whatever
+// work is already queued for them, are left untouched - this is the "drain
+// and re-shard" behavior: no mountpath restarts its walk from scratch just
+// because a sibling mountpath came or went.
+func (r *XactErase) reshard(availablePaths map[string]*fs.MountpathInfo) {
+	var seed []workItem
+
+	r.erasersMu.Lock()
+	keep := make(map[string]struct{}, len(availablePaths))
+	for _, mpathInfo := range availablePaths {
+		mpath := mpathInfo.MakePath(fs.ObjectType, r.Bislocal)
+		keep[mpath] = struct{}{}
+		if _, ok := r.erasers[mpath]; ok {
+			continue // unchanged, keep draining its in-flight/queued work as-is
+		}
+		j := &eraser{parent: r, mpathInfo: mpathInfo, mpath: mpath}
+		r.erasers[mpath] = j
+		dir := mpathInfo.MakePathBucket(fs.ObjectType, r.Bucket, r.Bislocal)
+		seed = append(seed, workItem{mpath: mpath, dir: dir})
+	}
+	for mpath, j := range r.erasers {
+		if _, ok := keep[mpath]; !ok {
+			atomic.StoreInt32(&j.invalid, 1)
+			delete(r.erasers, mpath)
+		}
+	}
+	r.erasersMu.Unlock()
+
+	// enqueue outside the lock: the queue can be full and block, and workers
+	// need erasersMu (briefly, in processDir) to keep draining it
+	for _, item := range seed {
+		select {
+		case r.queue <- item:
+		case <-r.done:
+			return
+		}
 	}
 }
 
 //
-// mpath eraser
+// worker pool
 //
-func (j *eraser) stop() { j.stopCh <- struct{}{}; close(j.stopCh) }
-
-func (j *eraser) jog() {
-	j.stopCh = make(chan struct{}, 1)
-	dir := j.mpathInfo.MakePathBucket(fs.ObjectType, j.parent.Bucket, j.parent.Bislocal)
-	if err := filepath.Walk(dir, j.walk); err != nil {
-		s := err.Error()
-		if strings.Contains(s, "xaction") {
-			glog.Infof("%s: stopping traversal: %s", dir, s)
-		} else {
-			glog.Errorf("%s: failed to traverse, err: %v", dir, err)
+
+// work is one worker-pool goroutine: it drains workItems off the shared
+// queue - directories seeded by reshard, or subdirectories discovered by
+// other workers - until r.done is closed. Multiple workers pulling off
+// the same channel is, in effect, the work-stealing this package needs:
+// whichever worker is free next picks up whatever directory is next in
+// line, regardless of which mountpath produced it.
+func (r *XactErase) work() {
+	defer r.workerWG.Done()
+	for {
+		select {
+		case item := <-r.queue:
+			r.processDir(item)
+		case <-r.done:
+			return
 		}
-		return
 	}
 }
 
-func (j *eraser) walk(fqn string, osfi os.FileInfo, err error) error {
+func (r *XactErase) processDir(item workItem) {
+	r.erasersMu.RLock()
+	j, ok := r.erasers[item.mpath]
+	r.erasersMu.RUnlock()
+	if !ok || atomic.LoadInt32(&j.invalid) != 0 {
+		return
+	}
+	entries, err := ioutil.ReadDir(item.dir)
 	if err != nil {
-		glog.Errorf("invoked with err: %v", err)
-		return err
+		atomic.AddInt64(&j.errors, 1)
+		glog.Errorf("%s: failed to list %s, err: %v", r, item.dir, err)
+		return
 	}
-	if osfi.Mode().IsDir() {
-		return nil
+	for _, entry := range entries {
+		if atomic.LoadInt32(&j.invalid) != 0 {
+			return
+		}
+		fqn := filepath.Join(item.dir, entry.Name())
+		if entry.IsDir() {
+			select {
+			case r.queue <- workItem{mpath: item.mpath, dir: fqn}:
+			case <-r.ChanAbort():
+				return
+			case <-r.done:
+				return
+			}
+			continue
+		}
+		r.eraseFile(j, fqn)
 	}
-	if err = j.yieldTerm(); err != nil {
-		return err
+}
+
+func (r *XactErase) eraseFile(j *eraser, fqn string) {
+	select {
+	case <-r.ChanAbort():
+		return
+	default:
 	}
-	lom := &cluster.LOM{T: j.parent.T, Fqn: fqn}
-	if errstr := lom.Fill(cluster.LomFstat|cluster.LomCopy, j.parent.config); errstr != "" || lom.Doesnotexist {
+	atomic.AddInt64(&j.filesVisited, 1)
+	lom := &cluster.LOM{T: r.T, Fqn: fqn}
+	if errstr := lom.Fill(cluster.LomFstat|cluster.LomCopy, r.config); errstr != "" || lom.Doesnotexist {
 		if glog.V(4) {
 			glog.Infof("Warning: %s", errstr)
 		}
-		return nil
+		return
 	}
 	// includes post-rebalancing cleanup
 	if lom.Misplaced {
 		glog.Infof("misplaced: %s, fqn=%s", lom, fqn)
-		return nil
+		return
+	}
+	if !lom.HasCopy() {
+		return
+	}
+	if !r.limiter.wait(lom.Size, r.done) {
+		return
+	}
+	if errstr := lom.DelCopy(); errstr != "" {
+		atomic.AddInt64(&j.errors, 1)
+		glog.Errorf("%s", errors.New(errstr))
+		return
+	}
+	atomic.AddInt64(&j.copiesDeleted, 1)
+	atomic.AddInt64(&j.bytesReclaimed, lom.Size)
+}
+
+//
+// rate limiter: a simple lazily-refilled token bucket, one bucket for ops
+// and one for bytes, so erasure throughput can be capped independently on
+// either axis without saturating a mountpath's disks.
+//
+
+type rateLimiter struct {
+	mu           sync.Mutex
+	opsPerSec    float64
+	bytesPerSec  float64
+	opsTokens    float64
+	bytesTokens  float64
+	lastRefilled time.Time
+}
+
+func newRateLimiter(opsPerSec, bytesPerSec float64) *rateLimiter {
+	return &rateLimiter{
+		opsPerSec:    opsPerSec,
+		bytesPerSec:  bytesPerSec,
+		lastRefilled: time.Now(),
 	}
-	if lom.HasCopy() {
-		if errstr := lom.DelCopy(); errstr != "" {
-			return errors.New(errstr)
+}
+
+// wait blocks, if necessary, until there's budget for one more erase op of
+// the given size, or until done fires (in which case it returns false and
+// the caller must not proceed with the op). opsPerSec/bytesPerSec of zero
+// disables the corresponding limit entirely.
+//
+// A size larger than the bucket's own capacity (bytesPerSec, one second's
+// worth) can never be waited for outright - refillLocked never lets
+// bytesTokens exceed bytesPerSec - so the wait threshold is capped at
+// bytesPerSec and the full size is still deducted, going negative. That
+// negative balance is exactly the number of seconds of amortization an
+// oversized op needs before the bucket is "caught up" again; any other
+// caller blocked on the same limiter waits that deficit out too, which is
+// the intended throttling effect for a reclaim far bigger than the rate.
+func (rl *rateLimiter) wait(size int64, done <-chan struct{}) bool {
+	if rl.opsPerSec <= 0 && rl.bytesPerSec <= 0 {
+		return true
+	}
+	for {
+		rl.mu.Lock()
+		rl.refillLocked()
+		bytesThreshold := float64(size)
+		if rl.bytesPerSec > 0 && bytesThreshold > rl.bytesPerSec {
+			bytesThreshold = rl.bytesPerSec
+		}
+		if (rl.opsPerSec <= 0 || rl.opsTokens >= 1) && (rl.bytesPerSec <= 0 || rl.bytesTokens >= bytesThreshold) {
+			if rl.opsPerSec > 0 {
+				rl.opsTokens--
+			}
+			if rl.bytesPerSec > 0 {
+				rl.bytesTokens -= float64(size)
+			}
+			rl.mu.Unlock()
+			return true
+		}
+		rl.mu.Unlock()
+		select {
+		case <-time.After(10 * time.Millisecond):
+		case <-done:
+			return false
 		}
 	}
-	return nil
 }
 
-func (j *eraser) yieldTerm() error {
-	select {
-	case <-j.stopCh:
-		return nil
-	default:
-		runtime.Gosched()
-		break
+func (rl *rateLimiter) refillLocked() {
+	now := time.Now()
+	elapsed := now.Sub(rl.lastRefilled).Seconds()
+	rl.lastRefilled = now
+	if rl.opsPerSec > 0 {
+		rl.opsTokens += elapsed * rl.opsPerSec
+		if rl.opsTokens > rl.opsPerSec {
+			rl.opsTokens = rl.opsPerSec
+		}
+	}
+	if rl.bytesPerSec > 0 {
+		rl.bytesTokens += elapsed * rl.bytesPerSec
+		if rl.bytesTokens > rl.bytesPerSec {
+			rl.bytesTokens = rl.bytesPerSec
+		}
 	}
-	return nil
 }
@@ -49,6 +49,12 @@ type (
 		UUID   string
 		Phase  string
 		Custom interface{} // Additional arguments that are specific for a given xaction.
+
+		// Cancel is the CancelFunc for Ctx, set by renewBucketXact when it derives
+		// a cancelable context for the renewed entry. Factories that spawn
+		// long-lived goroutines should retain it (e.g., on the xaction itself)
+		// so that a subsequent Cancel() call by the caller reliably unwinds them.
+		Cancel context.CancelFunc
 	}
 
 	DirPromoteArgs struct {
@@ -124,22 +130,96 @@ func RenewBucketXact(kind string, bck *cluster.Bck, args *XactArgs) (res RenewRe
 	return defaultReg.renewBucketXact(kind, bck, args)
 }
 
+// leaser is implemented by xactions that want a deterministic, lease-style
+// "still wanted" signal instead of the racy IncPending/DecPending nudge below.
+// Refresh resets the xaction's idle timer; the registry calls it when an
+// existing entry is renewed (see renewBucketXact). The registry does not track
+// leases itself: it neither cancels nor evicts an entry whose lease goes
+// unrefreshed.
+type leaser interface {
+	Refresh()
+}
+
+// doner is implemented by xactions that can report their own exit, backing
+// RenewRes.Done() below.
+type doner interface {
+	Done() <-chan struct{}
+}
+
+// closedDoneCh is what RenewRes.Done() returns for an entry whose xaction
+// doesn't implement doner - callers range/select on it like any other done
+// channel, and a channel that's already closed means "don't wait", which is
+// the right answer for an entry that can't report progress either way.
+var closedDoneCh = func() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// Cancel asks the entry's underlying xaction to unwind via the cancelable
+// interface (see XactArgs.Cancel). A concrete xaction wires this up simply by
+// retaining args.Cancel and calling it from a Cancel() method; entries whose
+// xaction doesn't implement cancelable are a no-op, same as canceling an
+// already-done context.
+func (res RenewRes) Cancel() {
+	if res.Entry == nil {
+		return
+	}
+	if c, ok := res.Entry.Get().(cancelable); ok {
+		c.Cancel()
+	}
+}
+
+// Done returns a channel that's closed once the entry's underlying xaction
+// has exited, so a caller that called Cancel() (or just wants to wait out a
+// natural finish) doesn't have to poll Finished(). For an entry whose
+// xaction doesn't implement doner, the returned channel is already closed.
+func (res RenewRes) Done() <-chan struct{} {
+	if res.Entry != nil {
+		if d, ok := res.Entry.Get().(doner); ok {
+			return d.Done()
+		}
+	}
+	return closedDoneCh
+}
+
+// NOTE: cancelable/doner are optional capabilities, deliberately checked via
+// type assertion rather than added to cluster.Xact or BucketEntry - cluster.Xact
+// is not declared in this checkout (see the package-level NOTE in
+// downloader/retry.go for the same situation), so no concrete xaction here
+// can be made to implement them without guessing at its real method set.
+// Once a concrete per-kind xaction factory retains args.Cancel/args.Ctx.Done()
+// (the XactArgs.Cancel doc comment above says where), Cancel()/Done() start
+// reaching it with no further changes to this file.
+
 func (r *registry) renewBucketXact(kind string, bck *cluster.Bck, args *XactArgs) (res RenewRes) {
 	if args == nil {
 		args = &XactArgs{}
 	}
+	if args.Ctx == nil {
+		args.Ctx = context.Background()
+	}
+	args.Ctx, args.Cancel = context.WithCancel(args.Ctx)
 	e := r.bckXacts[kind].New(args)
 	res = r.renewBckXact(e, bck)
 	if res.Err != nil {
+		args.Cancel()
 		return
 	}
 	if !res.IsNew {
+		// the freshly derived ctx/cancel above belong to an entry that was never
+		// started - release it right away so the derived context isn't leaked.
+		args.Cancel()
 		xact := res.Entry.Get()
-		// NOTE: make sure existing on-demand is active to prevent it from (idle) expiration
-		//       (see demand.go hkcb())
-		if xactDemand, ok := xact.(xaction.XactDemand); ok {
-			xactDemand.IncPending()
-			xactDemand.DecPending()
+		switch x := xact.(type) {
+		case leaser:
+			// lease-style refresh: bumps the idle timer without the inc/dec race
+			x.Refresh()
+		case xaction.XactDemand:
+			// NOTE: make sure existing on-demand is active to prevent it from (idle) expiration
+			//       (see demand.go hkcb())
+			x.IncPending()
+			x.DecPending()
 		}
 	}
 	return
@@ -321,15 +401,32 @@ func RenewBckSummary(ctx context.Context, t cluster.Target, bck *cluster.Bck, ms
 	return defaultReg.renewBckSummary(ctx, t, bck, msg)
 }
 
+// cancelable is implemented by task entries whose underlying xaction can be
+// asked to unwind deterministically (see XactArgs.Cancel above). Canceling the
+// outgoing entry before `del`+`Start` avoids the window where a racing second
+// renewal would otherwise leave the first entry's goroutine running as a zombie.
+type cancelable interface {
+	Cancel()
+}
+
 func (r *registry) renewBckSummary(ctx context.Context, t cluster.Target, bck *cluster.Bck, msg *cmn.BucketSummaryMsg) error {
+	ctx, cancel := context.WithCancel(ctx)
+
 	r.entries.mtx.Lock()
+	if prev := r.getXact(msg.UUID); prev != nil {
+		if c, ok := prev.(cancelable); ok {
+			c.Cancel()
+		}
+	}
 	err := r.entries.del(msg.UUID)
 	r.entries.mtx.Unlock()
 	if err != nil {
+		cancel()
 		return err
 	}
 	e := &bckSummaryTaskEntry{ctx: ctx, t: t, uuid: msg.UUID, msg: msg}
 	if err := e.Start(bck.Bck); err != nil {
+		cancel()
 		return err
 	}
 	r.add(e)
@@ -348,12 +445,26 @@ func (r *registry) RenewQuery(ctx context.Context, t cluster.Target, q *query.Ob
 		}
 		query.Registry.Delete(msg.UUID)
 	}
+	ctx, cancel := context.WithCancel(ctx)
+
 	r.entries.mtx.Lock()
+	// a racing second renewal must cancel the first outright rather than
+	// leaving its goroutine running with nothing left referencing it
+	if prev := r.getXact(msg.UUID); prev != nil {
+		if c, ok := prev.(cancelable); ok {
+			c.Cancel()
+		}
+	}
 	err := r.entries.del(msg.UUID)
 	r.entries.mtx.Unlock()
 	if err != nil {
+		cancel()
 		return RenewRes{&DummyEntry{nil}, err, false}
 	}
 	e := &queEntry{ctx: ctx, t: t, query: q, msg: msg}
-	return r.renewBckXact(e, q.BckSource.Bck)
+	res := r.renewBckXact(e, q.BckSource.Bck)
+	if res.Err != nil {
+		cancel()
+	}
+	return res
 }